@@ -0,0 +1,985 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	tt "tamtam/tamtam"
+	tir "tamtam/tamtam_image_renderer"
+)
+
+// Error due to a wrong usage of the command line, reported with exit code 2
+type usageError struct {
+	message string
+}
+
+func (err usageError) Error() string {
+	return err.message
+}
+
+func newUsageError(format string, a ...interface{}) error {
+	return usageError{fmt.Sprintf(format, a...)}
+}
+
+// Parses flags that can be placed before, between or after the positional arguments
+func parseArgs(flags *flag.FlagSet, args []string) (positional []string, err error) {
+	for {
+		if err := flags.Parse(args); err == flag.ErrHelp {
+			return nil, err
+		} else if err != nil {
+			return nil, usageError{err.Error()}
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// Parses the arguments and checks that exactly the expected number of positional arguments was given
+func parseArgsExactly(flags *flag.FlagSet, args []string, count int) ([]string, error) {
+	positional, err := parseArgs(flags, args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(positional) != count {
+		return nil, newUsageError("%s expects %d argument(s), got %d", flags.Name(), count, len(positional))
+	}
+
+	return positional, nil
+}
+
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: tamtam", usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// Reads a tile system, the format is given by the file extension: .tam for the tile system
+// description language, .tdp and .tds for ISU TAS, .pytas for PyTAS, .tiles for xgrow,
+// .tamb for the binary encoding, JSON otherwise
+func loadTileSystem(path string) (system tt.TileSystem, err error) {
+	if strings.ToLower(filepath.Ext(path)) == ".tdp" {
+		return tt.LoadTASSystem(path)
+	}
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return system, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tam":
+		system, err = tt.ParseTileSystemDSL(file)
+	case ".tds":
+		system.TileSet, system.GlueStrengths, err = tt.ReadTDS(file)
+		system.InitialTiles = make(tt.TileMap)
+		system.Threshold = tt.TAS_DEFAULT_THRESHOLD
+	case ".pytas":
+		system, err = tt.ReadPyTAS(file)
+	case ".tiles":
+		system, err = tt.ReadXgrowTiles(file)
+	case ".tamb":
+		system, err = tt.ReadTileSystemBinary(file)
+	default:
+		err = json.NewDecoder(file).Decode(&system)
+	}
+
+	if err == nil {
+		err = system.Validate()
+	}
+
+	if _, ok := err.(tt.DSLError); ok {
+		return system, fmt.Errorf("%s:%v", path, err)
+	} else if err != nil {
+		return system, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return system, nil
+}
+
+// Writes a tile system to the file, or as JSON to the standard output if path is "-".
+// The format is given by the file extension as in loadTileSystem.
+func saveTileSystem(system tt.TileSystem, path string) (err error) {
+	if path == "-" {
+		b, err := json.Marshal(system)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(append(b, '\n'))
+		return err
+	}
+
+	if strings.ToLower(filepath.Ext(path)) == ".tdp" {
+		return tt.SaveTASSystem(path, system)
+	}
+
+	file, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tam":
+		err = tt.WriteTileSystemDSL(file, system)
+	case ".tds":
+		err = tt.WriteTDS(file, system.TileSet, system.GlueStrengths)
+	case ".pytas":
+		err = tt.WritePyTAS(file, system)
+	case ".tiles":
+		err = tt.WriteXgrowTiles(file, system)
+	case ".tamb":
+		err = tt.WriteTileSystemBinary(file, system)
+	default:
+		err = json.NewEncoder(file).Encode(system)
+	}
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func loadAssembly(path string) (assembly tt.TileAssembly, err error) {
+	system, err := loadTileSystem(path)
+
+	if err != nil {
+		return assembly, err
+	}
+
+	return system.NewAssembly()
+}
+
+func saveAssembly(assembly tt.TileAssembly, path string) error {
+	return saveTileSystem(assembly.GetTileSystem(), path)
+}
+
+func convertCommand(args []string) error {
+	flags := newFlagSet("convert", "convert <input> <output>")
+
+	positional, err := parseArgsExactly(flags, args, 2)
+
+	if err != nil {
+		return err
+	}
+
+	// Streaming large binary files instead of loading them
+	if strings.ToLower(filepath.Ext(positional[0])) == ".tamb" &&
+		(positional[1] == "-" || strings.ToLower(filepath.Ext(positional[1])) == ".json") {
+		return streamBinaryToJSON(positional[0], positional[1])
+	}
+
+	system, err := loadTileSystem(positional[0])
+
+	if err != nil {
+		return err
+	}
+
+	return saveTileSystem(system, positional[1])
+}
+
+func streamBinaryToJSON(inputPath string, outputPath string) error {
+	input, err := os.Open(inputPath)
+
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	if outputPath == "-" {
+		return tt.ConvertBinaryToJSON(input, os.Stdout)
+	}
+
+	output, err := os.Create(outputPath)
+
+	if err != nil {
+		return err
+	}
+
+	if err := tt.ConvertBinaryToJSON(input, output); err != nil {
+		output.Close()
+		return fmt.Errorf("%s: %v", inputPath, err)
+	}
+
+	return output.Close()
+}
+
+// Assemblies that growAssembly grows, 2D or 3D
+type growable interface {
+	GrowSync(directed bool) (bool, error)
+	GrowAsync(directed bool) (bool, error)
+}
+
+// Grows the assembly for the given number of steps, until it is terminal if steps is 0.
+// Steps are synchronous rounds unless async is set, in which case a step adds a single tile.
+// afterStep, if not nil, is called after each step with the number of steps performed so far.
+func growAssembly(assembly growable, steps int, async bool, directed bool, afterStep func(performed int) error) (performed int, err error) {
+	for steps <= 0 || performed < steps {
+		var didGrow bool
+
+		if async {
+			didGrow, err = assembly.GrowAsync(directed)
+		} else {
+			didGrow, err = assembly.GrowSync(directed)
+		}
+
+		if err != nil || !didGrow {
+			return performed, err
+		}
+
+		performed += 1
+
+		if afterStep != nil {
+			if err := afterStep(performed); err != nil {
+				return performed, err
+			}
+		}
+	}
+	return performed, nil
+}
+
+func growCommand(args []string) error {
+	flags := newFlagSet("grow", "grow <assembly.json | checkpoint.json --resume> [flags]")
+	steps := flags.Int("steps", 0, "number of growth steps, grows until the assembly is terminal if 0")
+	out := flags.String("out", "-", "file where the grown assembly is written, - for the standard output")
+	async := flags.Bool("async", false, "asynchronous growth, each step adds a single tile")
+	seed := flags.Int64("seed", 0, "seed of the random generator used by asynchronous growth")
+	undirected := flags.Bool("undirected", false, "allows several tiles to fit the same position")
+	checkpoint := flags.String("checkpoint", "", "file where the full state of the assembly is saved periodically")
+	checkpointEvery := flags.Int("checkpoint-every", 1000, "number of growth steps between checkpoints")
+	resume := flags.Bool("resume", false, "the input is a checkpoint, growth resumes where it stopped (--seed is ignored)")
+	box := flags.String("box", "", "restricts growth to the region x0,y0,x1,y1")
+	torus := flags.String("torus", "", "grows on a torus of the given width,height")
+	orientations := flags.String("orientations", "", "lets tiles be placed rotated (rotations) or rotated and reflected (rotations_and_reflections)")
+	detach := flags.Bool("detach", false, "tiles made unstable by negative glues detach instead of staying in place")
+
+	positional, err := parseArgsExactly(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	if *checkpointEvery <= 0 {
+		return newUsageError("--checkpoint-every must be positive")
+	}
+
+	var assembly tt.TileAssembly
+
+	if *resume {
+		assembly, err = tt.LoadCheckpoint(positional[0])
+	} else {
+		assembly, err = loadAssembly(positional[0])
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !*resume {
+		assembly.SetRandomSeed(*seed)
+	}
+
+	if *orientations != "" {
+		if err := assembly.SetOrientations(tt.Orientations(*orientations)); err != nil {
+			return newUsageError("--orientations: %v", err)
+		}
+	}
+
+	if *detach {
+		if err := assembly.SetDetachment(tt.DETACHMENT_UNSTABLE); err != nil {
+			return newUsageError("--detach: %v", err)
+		}
+	}
+
+	if *box != "" && *torus != "" {
+		return newUsageError("--box and --torus cannot be used together")
+	}
+
+	if *box != "" {
+		region, err := parseRegion(*box)
+
+		if err != nil {
+			return err
+		}
+
+		if err := assembly.SetGeometry(tt.NewBoundedGeometry(region.LowerLeft, region.UpperRight)); err != nil {
+			return newUsageError("--box: %v", err)
+		}
+	}
+
+	if *torus != "" {
+		size, err := parseInts(*torus, 2, "a torus size width,height")
+
+		if err != nil {
+			return err
+		}
+
+		if err := assembly.SetGeometry(tt.NewTorusGeometry(size[0], size[1])); err != nil {
+			return newUsageError("--torus: %v", err)
+		}
+	}
+
+	var afterStep func(int) error
+
+	if *checkpoint != "" {
+		afterStep = func(performed int) error {
+			if performed%*checkpointEvery == 0 {
+				return assembly.SaveCheckpoint(*checkpoint)
+			}
+			return nil
+		}
+	}
+
+	performed, err := growAssembly(&assembly, *steps, *async, !*undirected, afterStep)
+
+	if *checkpoint != "" {
+		if checkpointErr := assembly.SaveCheckpoint(*checkpoint); err == nil {
+			err = checkpointErr
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "Performed", performed, "growth steps, assembly has", assembly.Size(), "tiles")
+
+	return saveAssembly(assembly, *out)
+}
+
+// Parses count comma separated integers, format describes the expected input in errors
+func parseInts(encoded string, count int, format string) ([]int, error) {
+	parts := strings.Split(encoded, ",")
+
+	if len(parts) != count {
+		return nil, newUsageError("expected %s, got %q", format, encoded)
+	}
+
+	values := make([]int, count)
+	for i, part := range parts {
+		var err error
+		values[i], err = strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, newUsageError("expected %s, got %q", format, encoded)
+		}
+	}
+
+	return values, nil
+}
+
+// Parses a lattice name, "square" being the default lattice
+func parseLattice(name string) (tt.Lattice, error) {
+	if name == tt.LATTICE_SQUARE.String() {
+		return tt.LATTICE_SQUARE, nil
+	}
+
+	lattice := tt.Lattice(name)
+
+	if err := lattice.Validate(); err != nil {
+		return lattice, newUsageError("%v", err)
+	}
+
+	return lattice, nil
+}
+
+// Parses a region given as x0,y0,x1,y1
+func parseRegion(encoded string) (*tir.Region, error) {
+	coordinates, err := parseInts(encoded, 4, "a region x0,y0,x1,y1")
+
+	if err != nil {
+		return nil, err
+	}
+
+	region := tir.Region{LowerLeft: tt.Vec2Di{coordinates[0], coordinates[1]}, UpperRight: tt.Vec2Di{coordinates[2], coordinates[3]}}
+
+	if err := region.Validate(); err != nil {
+		return nil, newUsageError("%v", err)
+	}
+
+	return &region, nil
+}
+
+func renderCommand(args []string) error {
+	flags := newFlagSet("render", "render <assembly.json> [flags]")
+	pngPath := flags.String("png", "", "writes a PNG picture of the assembly")
+	svgPath := flags.String("svg", "", "writes an SVG picture of the assembly")
+	sheetPath := flags.String("sheet", "", "writes an SVG sheet of the tile set")
+	gifPath := flags.String("gif", "", "grows the assembly and writes its growth as an animated GIF")
+	framesDirectory := flags.String("frames", "", "grows the assembly and writes its growth as numbered PNG files in that directory")
+	asyncStepsPerFrame := flags.Int("async-steps", 0, "growth frames are taken every that many asynchronous steps instead of every synchronous round")
+	maxFrames := flags.Int("max-frames", 0, "maximum number of growth frames, no limit if 0")
+	seed := flags.Int64("seed", 0, "seed of the random generator used by asynchronous growth")
+	tileSize := flags.Int("tile-size", tir.TILE_SIZE, "size of a tile in pixels")
+	region := flags.String("region", "", "only renders the positions in x0,y0,x1,y1 (corners included)")
+	showGrid := flags.Bool("grid", true, "draws the grid")
+	showText := flags.Bool("text", false, "draws tile and glue names")
+
+	positional, err := parseArgsExactly(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	if *pngPath == "" && *svgPath == "" && *sheetPath == "" && *gifPath == "" && *framesDirectory == "" {
+		return newUsageError("render needs at least one of --png, --svg, --sheet, --gif or --frames")
+	}
+
+	if *tileSize <= 0 {
+		return newUsageError("tile size must be positive")
+	}
+
+	assembly, err := loadAssembly(positional[0])
+
+	if err != nil {
+		return err
+	}
+
+	params := tir.NewRenderParameters()
+	params.TileSize = *tileSize
+	params.ShowGrid = *showGrid
+	params.ShowTilesText = *showText
+
+	if *region != "" {
+		if params.Region, err = parseRegion(*region); err != nil {
+			return err
+		}
+	}
+
+	if *pngPath != "" {
+		if err := tir.SavePNG(*pngPath, assembly, params); err != nil {
+			return err
+		}
+	}
+
+	if *svgPath != "" {
+		if err := tir.SaveAssemblySVG(*svgPath, assembly, params); err != nil {
+			return err
+		}
+	}
+
+	if *sheetPath != "" {
+		if err := tir.CheckLattice(assembly.GetLattice()); err != nil {
+			return err
+		}
+
+		file, err := os.Create(*sheetPath)
+
+		if err != nil {
+			return err
+		}
+
+		if err := tir.WriteTileSetSheetSVG(file, assembly.TileSet, 0, params); err != nil {
+			file.Close()
+			return err
+		}
+
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+
+	if *gifPath == "" && *framesDirectory == "" {
+		return nil
+	}
+
+	animationParams := tir.NewAnimationParameters()
+	animationParams.RenderParameters = params
+	animationParams.AsyncStepsPerFrame = *asyncStepsPerFrame
+	animationParams.MaxFrames = *maxFrames
+	assembly.SetRandomSeed(*seed)
+
+	frames, err := tir.RecordGrowth(&assembly, animationParams)
+
+	if err != nil {
+		return err
+	}
+
+	if *gifPath != "" {
+		if err := tir.SaveGIF(*gifPath, frames, animationParams.FrameDelay); err != nil {
+			return err
+		}
+	}
+
+	if *framesDirectory != "" {
+		if err := tir.SaveFrames(*framesDirectory, frames); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Loads a 3D assembly, which is always JSON
+func loadAssembly3D(path string) (assembly tt.TileAssembly3D, err error) {
+	b, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return assembly, err
+	}
+
+	if err := json.Unmarshal(b, &assembly); err != nil {
+		return assembly, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return assembly, nil
+}
+
+// Writes the slices of a 3D assembly as PNG files, after growing it if asked to
+func slicesCommand(args []string) error {
+	flags := newFlagSet("slices", "slices <assembly3d.json> --out directory [flags]")
+	out := flags.String("out", "", "directory where slice_<z>.png files are written")
+	grow := flags.Bool("grow", false, "grows the assembly before slicing it")
+	steps := flags.Int("steps", 0, "number of growth steps with --grow, grows until the assembly is terminal if 0")
+	async := flags.Bool("async", false, "asynchronous growth, each step adds a single tile")
+	seed := flags.Int64("seed", 0, "seed of the random generator used by asynchronous growth")
+	undirected := flags.Bool("undirected", false, "allows several tiles to fit the same position")
+	tileSize := flags.Int("tile-size", tir.TILE_SIZE, "size of a tile in pixels")
+	showGrid := flags.Bool("grid", true, "draws the grid")
+	showText := flags.Bool("text", false, "draws tile and glue names")
+
+	positional, err := parseArgsExactly(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return newUsageError("slices needs --out")
+	}
+
+	if *tileSize <= 0 {
+		return newUsageError("tile size must be positive")
+	}
+
+	assembly, err := loadAssembly3D(positional[0])
+
+	if err != nil {
+		return err
+	}
+
+	if *grow {
+		assembly.SetRandomSeed(*seed)
+
+		if _, err := growAssembly(&assembly, *steps, *async, !*undirected, nil); err != nil {
+			return err
+		}
+	}
+
+	params := tir.NewRenderParameters()
+	params.TileSize = *tileSize
+	params.ShowGrid = *showGrid
+	params.ShowTilesText = *showText
+
+	if err := tir.SaveSlices(*out, assembly, params); err != nil {
+		return err
+	}
+
+	fmt.Println("Wrote", len(assembly.GetTileMap().Heights()), "slices of an assembly of", assembly.Size(), "tiles")
+	return nil
+}
+
+// Loads a tile set alone or the one of a tile system, with the lattice of the tile system
+func loadTileSet(path string) (tt.TileSet, tt.Lattice, error) {
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		b, err := ioutil.ReadFile(path)
+
+		if err != nil {
+			return nil, tt.LATTICE_SQUARE, err
+		}
+
+		var tileSet tt.TileSet
+
+		// Tile set alone, otherwise reading the tile system
+		if err := json.Unmarshal(b, &tileSet); err == nil {
+			if _, isSystem := tileSet["tile_set"]; !isSystem {
+				return tileSet, tt.LATTICE_SQUARE, nil
+			}
+		}
+	}
+
+	system, err := loadTileSystem(path)
+
+	return system.TileSet, system.Lattice, err
+}
+
+func validateCommand(args []string) error {
+	flags := newFlagSet("validate", "validate <tile_set>")
+	latticeName := flags.String("lattice", "", "lattice of the tiles (square, hexagonal or triangular), defaults to the one of the tile system")
+
+	positional, err := parseArgsExactly(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	tileSet, lattice, err := loadTileSet(positional[0])
+
+	if err != nil {
+		return err
+	}
+
+	if *latticeName != "" {
+		if lattice, err = parseLattice(*latticeName); err != nil {
+			return err
+		}
+	}
+
+	errs, warnings := tileSet.ValidateOnLattice(lattice)
+
+	for _, warning := range warnings {
+		fmt.Println("warning:", warning)
+	}
+
+	for _, err := range errs {
+		fmt.Println("error:", err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s: %d error(s) in tile set", positional[0], len(errs))
+	}
+
+	fmt.Println(positional[0]+":", len(tileSet), "tile types, no error")
+	return nil
+}
+
+func statsCommand(args []string) error {
+	flags := newFlagSet("stats", "stats <assembly.json> [flags]")
+	asJSON := flags.Bool("json", false, "prints the statistics as JSON instead of a table")
+	checkpoint := flags.Bool("checkpoint", false, "the input is a checkpoint (growth steps are only known from checkpoints)")
+
+	positional, err := parseArgsExactly(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	var assembly tt.TileAssembly
+
+	if *checkpoint {
+		assembly, err = tt.LoadCheckpoint(positional[0])
+	} else {
+		assembly, err = loadAssembly(positional[0])
+	}
+
+	if err != nil {
+		return err
+	}
+
+	stats := assembly.Statistics()
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	return stats.WriteTable(os.Stdout)
+}
+
+// Grows the assembly many times and prints the distribution of the terminal assemblies
+func monteCarloCommand(args []string) error {
+	flags := newFlagSet("montecarlo", "montecarlo <assembly.json> [flags]")
+	runs := flags.Int("runs", 100, "number of runs")
+	seed := flags.Int64("seed", 0, "random seed of the first run, the following runs use the next seeds")
+	steps := flags.Int("steps", 0, "growth steps after which a run stops, runs grow until their assembly is terminal if 0")
+	shapes := flags.Int("shapes", 10, "number of most frequent shapes listed")
+	asJSON := flags.Bool("json", false, "prints the distribution and every shape as JSON instead of tables")
+
+	positional, err := parseArgsExactly(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	system, err := loadTileSystem(positional[0])
+
+	if err != nil {
+		return err
+	}
+
+	result, err := tt.RunMonteCarlo(system, *runs, *seed, *steps)
+
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	return result.WriteTable(os.Stdout, *shapes)
+}
+
+// Runs a staged system and prints its complexity, writing the products of each bin if asked to
+func stageCommand(args []string) error {
+	flags := newFlagSet("stage", "stage <staged_system.json> [flags]")
+	out := flags.String("out", "", "directory where the products of each bin are written as <bin>_<i>.json")
+	maxAssemblies := flags.Int("max-assemblies", 0, "assemblies after which a bin is an error, overrides the one of the system")
+	asJSON := flags.Bool("json", false, "prints the complexity and the products as JSON instead of a table")
+
+	positional, err := parseArgsExactly(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	b, err := ioutil.ReadFile(positional[0])
+
+	if err != nil {
+		return err
+	}
+
+	var system tt.StagedSystem
+
+	if err := json.Unmarshal(b, &system); err != nil {
+		return fmt.Errorf("%s: %v", positional[0], err)
+	}
+
+	if *maxAssemblies > 0 {
+		system.MaxAssemblies = *maxAssemblies
+	}
+
+	result, err := system.Run()
+
+	if err != nil {
+		return err
+	}
+
+	if *out != "" {
+		if err := os.MkdirAll(*out, 0755); err != nil {
+			return err
+		}
+
+		for name, products := range result.Products {
+			for i, product := range products {
+				if err := saveAssembly(product, filepath.Join(*out, fmt.Sprintf("%s_%d.json", name, i))); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	return result.WriteTable(os.Stdout)
+}
+
+// Prints the differences between two assemblies, fails if there are any so that it can be used in scripts
+func diffCommand(args []string) error {
+	flags := newFlagSet("diff", "diff <first.json> <second.json> [flags]")
+	align := flags.Bool("align", false, "translates the second assembly so that the lower left corners of the bounding boxes coincide")
+	asJSON := flags.Bool("json", false, "prints the differences as JSON")
+
+	positional, err := parseArgsExactly(flags, args, 2)
+
+	if err != nil {
+		return err
+	}
+
+	first, err := loadAssembly(positional[0])
+
+	if err != nil {
+		return err
+	}
+
+	second, err := loadAssembly(positional[1])
+
+	if err != nil {
+		return err
+	}
+
+	var diff tt.TileMapDiff
+
+	if *align {
+		diff = first.GetTileMap().AlignedDiff(second.GetTileMap())
+	} else {
+		diff = first.GetTileMap().Diff(second.GetTileMap())
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(diff)
+	} else {
+		err = diff.WriteText(os.Stdout, first.GetOrientedTileSet())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !diff.IsEmpty() {
+		return fmt.Errorf("%d differences", diff.Count())
+	}
+
+	return nil
+}
+
+// Grows the assembly until it is terminal and checks that it has the target shape up to translation,
+// fails if it does not so that it can be used in scripts
+func verifyCommand(args []string) error {
+	flags := newFlagSet("verify", "verify <assembly.json> <shape> [flags]")
+	steps := flags.Int("steps", 10000, "synchronous growth steps after which the assembly is considered not to be terminal, no limit if 0")
+	asJSON := flags.Bool("json", false, "prints the missing and extra positions as JSON")
+
+	positional, err := parseArgsExactly(flags, args, 2)
+
+	if err != nil {
+		return err
+	}
+
+	system, err := loadTileSystem(positional[0])
+
+	if err != nil {
+		return err
+	}
+
+	target, err := tt.LoadShape(positional[1])
+
+	if err != nil {
+		return err
+	}
+
+	verification, _, err := tt.VerifyTerminalShape(system, target, *steps)
+
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(verification)
+	} else {
+		err = verification.WriteText(os.Stdout)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !verification.IsExact() {
+		return fmt.Errorf("%d missing and %d extra positions", len(verification.Missing), len(verification.Extra))
+	}
+
+	return nil
+}
+
+// Seed of the CRT demo: a column of east glues "0" and a row of north glues "0" except for a "1" in the corner
+func crtSeed(size int) tt.TileMap {
+	seed := make(tt.TileMap)
+
+	for i := 0; i < size; i += 1 {
+		seed[tt.Vec2Di{-1, i}] = tt.SquareGlues{tt.NULL_GLUE, "0", tt.NULL_GLUE, tt.NULL_GLUE}
+	}
+
+	seed[tt.Vec2Di{0, -1}] = tt.SquareGlues{"1", tt.NULL_GLUE, tt.NULL_GLUE, tt.NULL_GLUE}
+
+	for i := 0; i < size-1; i += 1 {
+		seed[tt.Vec2Di{1 + i, -1}] = tt.SquareGlues{"0", tt.NULL_GLUE, tt.NULL_GLUE, tt.NULL_GLUE}
+	}
+
+	return seed
+}
+
+func genCommand(args []string) error {
+	if len(args) == 0 {
+		return newUsageError("gen expects the kind of tile set to generate, available: crt, shape, square")
+	}
+
+	switch args[0] {
+	case "crt":
+		flags := newFlagSet("gen crt", "gen crt [flags]")
+		p := flags.Int("p", 2, "first modulus")
+		q := flags.Int("q", 3, "second modulus, co-prime with p")
+		size := flags.Int("size", 20, "size of the seed")
+		out := flags.String("out", "-", "file where the seed assembly is written, - for the standard output")
+
+		if _, err := parseArgsExactly(flags, args[1:], 0); err != nil {
+			return err
+		}
+
+		tileSet, err := tt.NewCrtTileSet(*p, *q)
+
+		if err != nil {
+			return err
+		}
+
+		return saveAssembly(tt.NewAssembly(tileSet, crtSeed(*size), 2), *out)
+	case "shape":
+		flags := newFlagSet("gen shape", "gen shape <shape> [flags]")
+		construction := flags.String("construction", string(tt.CONSTRUCTION_SPANNING_TREE), "spanning_tree (one tile type per position) or rows (fewer tile types)")
+		out := flags.String("out", "-", "file where the seed assembly is written, - for the standard output")
+
+		positional, err := parseArgsExactly(flags, args[1:], 1)
+
+		if err != nil {
+			return err
+		}
+
+		if err := tt.ShapeConstruction(*construction).Validate(); err != nil {
+			return newUsageError("--construction: %v", err)
+		}
+
+		shape, err := tt.LoadShape(positional[0])
+
+		if err != nil {
+			return err
+		}
+
+		compiled, err := tt.CompileShape(shape, tt.ShapeConstruction(*construction))
+
+		if err != nil {
+			return err
+		}
+
+		return saveAssembly(compiled.NewAssembly(), *out)
+	case "square":
+		flags := newFlagSet("gen square", "gen square [flags]")
+		n := flags.Int("n", 10, "width and height of the square")
+		out := flags.String("out", "-", "file where the seed assembly is written, - for the standard output")
+
+		if _, err := parseArgsExactly(flags, args[1:], 0); err != nil {
+			return err
+		}
+
+		system, err := tt.NewSquareTileSystem(*n)
+
+		if err != nil {
+			return err
+		}
+
+		assembly, err := system.NewAssembly()
+
+		if err != nil {
+			return err
+		}
+
+		return saveAssembly(assembly, *out)
+	}
+
+	return newUsageError("unknown tile set kind %q, available: crt, shape, square", args[0])
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 	tt "tamtam/tamtam"
 	ttr "tamtam/tamtam_sdl2_renderer"
 
@@ -9,41 +11,6 @@ import (
 	"github.com/veandco/go-sdl2/ttf"
 )
 
-func newAssembly() tt.TileAssembly {
-	SIZE := 20
-	tileSet, err := tt.NewCrtTileSet(2, 3)
-
-	if err != nil {
-		panic(err)
-	}
-
-	var initialAssembly = make(map[tt.Vec2Di]tt.SquareGlues)
-
-	for i := 0; i < SIZE; i += 1 {
-		initialAssembly[tt.Vec2Di{-1, i}] = tt.SquareGlues{tt.NULL_GLUE, "0", tt.NULL_GLUE, tt.NULL_GLUE}
-	}
-
-	initialAssembly[tt.Vec2Di{0, -1}] = tt.SquareGlues{"1", tt.NULL_GLUE, tt.NULL_GLUE, tt.NULL_GLUE}
-
-	for i := 0; i < SIZE-1; i += 1 {
-		initialAssembly[tt.Vec2Di{1 + i, -1}] = tt.SquareGlues{"0", tt.NULL_GLUE, tt.NULL_GLUE, tt.NULL_GLUE}
-	}
-
-	var assembly = tt.NewAssembly(tileSet, initialAssembly, 2)
-
-	didGrow, err := assembly.GrowSync(true)
-
-	for didGrow && err == nil {
-		didGrow, err = assembly.GrowSync(true)
-	}
-
-	if err != nil {
-		panic(err)
-	}
-
-	return assembly
-}
-
 func countNumberKeyPressed() (count int) {
 	for _, value := range sdl.GetKeyboardState() {
 		if value != 0 {
@@ -64,34 +31,111 @@ func translationSpeed(mod uint16) int {
 	return 1
 }
 
-func main() {
+const USAGE = `Usage: tamtam <command> [arguments]
+
+Commands:
+  view <assembly.json> [--diff other.json]
+                                        opens the assembly in a window
+  grow <assembly.json> [--steps N] [--out out.json] [--checkpoint state.json]
+                                        grows the assembly, saving its full state periodically
+                                        with --checkpoint (grow state.json --resume continues),
+                                        within a box or on a torus with --box x0,y0,x1,y1 or --torus w,h
+  render <assembly.json> [--png out.png] [--svg out.svg] [--gif out.gif]
+                                        renders the assembly without opening a window
+  slices <assembly3d.json> --out dir [--grow]
+                                        writes each z slice of a 3D assembly as a PNG file
+  validate <tile_set>                   checks a tile set for errors
+  stats <assembly.json> [--json]        prints statistics about the assembly
+  montecarlo <assembly.json> [--runs N] [--seed S]
+                                        grows the assembly many times and prints the distribution
+                                        of terminal sizes and shapes
+  stage <staged_system.json> [--out dir] [--max-assemblies N]
+                                        runs a staged system, two-handed in each bin, printing its
+                                        stage, bin and tile complexity and writing the terminal
+                                        products of each bin
+  diff <first.json> <second.json> [--align] [--json]
+                                        lists the positions where the assemblies differ
+  verify <assembly.json> <shape>        grows the assembly until it is terminal and checks that it has the
+                                        shape (ASCII art, or a .pbm image) up to translation
+  convert <input> <output>              converts between tile system formats
+  gen crt [--p 2] [--q 3]               generates the seed assembly of a Chinese Remainder tile set
+  gen shape <shape> [--construction rows]
+                                        generates a tile set and seed that self-assemble the shape
+  gen square [--n 10]                   generates a temperature 2 binary counter tile set and seed
+                                        that self-assemble the n×n square
+
+Files ending in .tam use the tile system description language, .tdp and .tds the ISU TAS
+formats (.tds only holds a tile set), .pytas the PyTAS format, .tiles the xgrow format,
+.tamb a compact binary format for large assemblies, other files are JSON.
+Run tamtam <command> -h for the flags of each command.`
+
+// Opens a window showing the assembly, growth is performed by pressing n
+func viewCommand(args []string) error {
+	flags := newFlagSet("view", "view <assembly.json> [flags]")
+	diffWith := flags.String("diff", "", "assembly whose differences with the viewed one are overlaid, toggled with o")
+	align := flags.Bool("align", false, "aligns the bounding boxes of the assemblies before comparing them")
+
+	positional, err := parseArgsExactly(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	assembly, err := loadAssembly(positional[0])
+
+	if err != nil {
+		return err
+	}
+
+	var otherAssembly tt.TileAssembly
+	showDiff := *diffWith != ""
+
+	if showDiff {
+		if otherAssembly, err = loadAssembly(*diffWith); err != nil {
+			return err
+		}
+	}
+
+	computeDiff := func() tt.TileMapDiff {
+		if *align {
+			return assembly.GetTileMap().AlignedDiff(otherAssembly.GetTileMap())
+		}
+		return assembly.GetTileMap().Diff(otherAssembly.GetTileMap())
+	}
+
+	var diff tt.TileMapDiff
+	if showDiff {
+		diff = computeDiff()
+		fmt.Println(diff.Count(), "differences with", *diffWith)
+	}
 
 	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
-		panic(err)
+		return err
 	}
 	defer sdl.Quit()
 
 	if err := ttf.Init(); err != nil {
-		panic(err)
+		return err
 	}
-	defer sdl.Quit()
+	defer ttf.Quit()
 
 	window, err := sdl.CreateWindow("tamtam - v0.0.1", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
 		1200, 800, sdl.WINDOW_SHOWN)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	defer window.Destroy()
 
 	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_TARGETTEXTURE)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	defer renderer.Destroy()
 
-	assembly := newAssembly()
-
-	assemblyRender := ttr.NewSDL2AssemblyRenderer(&assembly, renderer)
+	assemblyRender, err := ttr.NewSDL2AssemblyRenderer(&assembly, renderer)
+	if err != nil {
+		return err
+	}
 	defer assemblyRender.Destroy()
 
 	uiParameters := ttr.NewUIParameters()
@@ -144,10 +188,19 @@ func main() {
 					case sdl.K_n:
 						assembly.GrowSync(true)
 						assemblyRender.UpdateTextures()
+						if *diffWith != "" {
+							diff = computeDiff()
+						}
+						break
+
+					case sdl.K_o:
+						showDiff = *diffWith != "" && !showDiff
 						break
 
 					case sdl.K_s:
-						fmt.Println(" Summary\n", "========\n", "Number of tiles:", assembly.Size(), "\n", "Number of textures:", assemblyRender.CountTextures())
+						fmt.Println(" Summary\n", "========")
+						assembly.Statistics().WriteTable(os.Stdout)
+						fmt.Println("Number of textures:", assemblyRender.CountTextures())
 						break
 					case sdl.K_f:
 						fmt.Println("Current FPS: ", 1/frameTime)
@@ -184,6 +237,10 @@ func main() {
 		renderer.Clear()
 		assemblyRender.Render(uiParameters)
 
+		if showDiff {
+			assemblyRender.RenderDiffOverlay(diff, uiParameters)
+		}
+
 		renderer.Present()
 
 		sdl.Delay(20)
@@ -194,4 +251,53 @@ func main() {
 		totalFrameTicks += int(endTicks) - int(startTicks)
 
 	}
+
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, USAGE)
+		os.Exit(2)
+	}
+
+	commands := map[string]func([]string) error{
+		"view":       viewCommand,
+		"grow":       growCommand,
+		"render":     renderCommand,
+		"slices":     slicesCommand,
+		"validate":   validateCommand,
+		"stats":      statsCommand,
+		"stage":      stageCommand,
+		"montecarlo": monteCarloCommand,
+		"diff":       diffCommand,
+		"verify":     verifyCommand,
+		"convert":    convertCommand,
+		"gen":        genCommand,
+	}
+
+	command, ok := commands[os.Args[1]]
+
+	if !ok {
+		if os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help" {
+			fmt.Println(USAGE)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s\n", os.Args[1], USAGE)
+		os.Exit(2)
+	}
+
+	err := command(os.Args[2:])
+
+	if err == flag.ErrHelp {
+		return
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tamtam:", err)
+		if _, ok := err.(usageError); ok {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
 }
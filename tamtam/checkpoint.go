@@ -0,0 +1,118 @@
+package tamtam
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Complete state of an assembly. Unlike the JSON encoding of TileAssembly, which only keeps
+// what is needed to build the assembly again, it keeps the order of the frontier, the step
+// counter and the state of the random generator, so that growth resumed from a checkpoint
+// goes on exactly as it would have without interruption. Observers are not saved.
+type checkpoint struct {
+	TileSystem
+	Frontier []Vec2Di `json:"frontier"`
+	// Tiles placed by growth, which can detach unlike the initial tiles
+	GrownTiles  []Vec2Di `json:"grown_tiles,omitempty"`
+	Steps       int      `json:"steps"`
+	RandomState uint64   `json:"random_state"`
+}
+
+func (assembly TileAssembly) WriteCheckpoint(w io.Writer) error {
+	return json.NewEncoder(w).Encode(checkpoint{
+		TileSystem:  assembly.GetTileSystem(),
+		Frontier:    assembly.emptyPositionsAboveThreshold.Positions(),
+		GrownTiles:  assembly.grownTiles.SortedPositions(),
+		Steps:       assembly.steps,
+		RandomState: assembly.randomSource.state,
+	})
+}
+
+func ReadCheckpoint(r io.Reader) (assembly TileAssembly, err error) {
+	var saved checkpoint
+
+	if err := json.NewDecoder(r).Decode(&saved); err != nil {
+		return assembly, err
+	}
+
+	if assembly, err = saved.NewAssembly(); err != nil {
+		return assembly, err
+	}
+
+	// The frontier is rebuilt from the tiles, the checkpoint only gives its order
+	if len(saved.Frontier) != assembly.emptyPositionsAboveThreshold.Len() {
+		return assembly, errors.New("the frontier of the checkpoint does not match its tiles")
+	}
+
+	frontier := newPositionSet()
+	for _, pos := range saved.Frontier {
+		if !assembly.emptyPositionsAboveThreshold.Contains(pos) {
+			return assembly, errors.New("the frontier of the checkpoint does not match its tiles")
+		}
+		frontier.Add(pos)
+	}
+
+	assembly.emptyPositionsAboveThreshold = frontier
+
+	for _, pos := range saved.GrownTiles {
+		if _, ok := assembly.tileMap[pos]; !ok {
+			return assembly, errors.New("a grown tile of the checkpoint is missing")
+		}
+
+		if assembly.grownTiles == nil {
+			assembly.grownTiles = make(Shape)
+		}
+		assembly.grownTiles[pos] = true
+	}
+
+	assembly.steps = saved.Steps
+	assembly.randomSource.state = saved.RandomState
+
+	return assembly, nil
+}
+
+// Writes the checkpoint to a temporary file which then replaces the file at the
+// given path, so that an interruption never leaves a partial checkpoint behind
+func (assembly TileAssembly) SaveCheckpoint(path string) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+
+	if err != nil {
+		return err
+	}
+
+	err = assembly.WriteCheckpoint(file)
+
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	return nil
+}
+
+func LoadCheckpoint(path string) (TileAssembly, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return TileAssembly{}, err
+	}
+	defer file.Close()
+
+	return ReadCheckpoint(file)
+}
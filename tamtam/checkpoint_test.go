@@ -0,0 +1,62 @@
+package tamtam
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Testing that asynchronous growth resumed from a checkpoint adds the same
+// tiles in the same order as growth that was never interrupted
+func TestCheckpointResume(t *testing.T) {
+	grown := grownCrtAssembly(t, 10)
+
+	seed := make(map[Vec2Di]SquareGlues)
+	for pos, tile := range grown.GetTileMap() {
+		if pos[0] == -1 || pos[1] == -1 {
+			seed[pos] = tile
+		}
+	}
+
+	assembly := NewAssembly(grown.TileSet, seed, 2)
+	assembly.SetRandomSeed(7)
+
+	for i := 0; i < 30; i += 1 {
+		if _, err := assembly.GrowAsync(true); err != nil {
+			t.Fatalf(`%v`, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	if err := assembly.SaveCheckpoint(path); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	resumed, err := LoadCheckpoint(path)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if resumed.GetSteps() != 30 || !resumed.IsEqualTo(assembly) {
+		t.Fatalf(`Resumed assembly has %d steps and differs from the saved one`, resumed.GetSteps())
+	}
+
+	changes, resumedChanges := NewChangeLog(), NewChangeLog()
+	assembly.AddObserver(changes)
+	resumed.AddObserver(resumedChanges)
+
+	for i := 0; i < 40; i += 1 {
+		if _, err := assembly.GrowAsync(true); err != nil {
+			t.Fatalf(`%v`, err)
+		}
+		if _, err := resumed.GrowAsync(true); err != nil {
+			t.Fatalf(`%v`, err)
+		}
+	}
+
+	if !reflect.DeepEqual(resumedChanges.GetAddedTiles(), changes.GetAddedTiles()) {
+		t.Fatalf(`Resumed growth differs from uninterrupted growth`)
+	}
+}
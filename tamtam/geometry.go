@@ -0,0 +1,92 @@
+package tamtam
+
+import "errors"
+
+const (
+	// The infinite plane, the default
+	GEOMETRY_PLANE = ""
+	// Growth only happens inside the rectangle
+	GEOMETRY_BOUNDED = "bounded"
+	// Positions wrap around the rectangle (periodic boundary conditions)
+	GEOMETRY_TORUS = "torus"
+)
+
+// Space in which an assembly grows. The zero value is the infinite plane.
+type Geometry struct {
+	Kind string `json:"kind"`
+	// Corners of the rectangle (both included) of bounded and torus geometries
+	LowerLeft  Vec2Di `json:"lower_left"`
+	UpperRight Vec2Di `json:"upper_right"`
+}
+
+func NewBoundedGeometry(lowerLeft Vec2Di, upperRight Vec2Di) Geometry {
+	return Geometry{Kind: GEOMETRY_BOUNDED, LowerLeft: lowerLeft, UpperRight: upperRight}
+}
+
+// Torus whose positions are taken modulo width and height, the fundamental
+// rectangle going from [0, 0] to [width - 1, height - 1]
+func NewTorusGeometry(width int, height int) Geometry {
+	return Geometry{Kind: GEOMETRY_TORUS, UpperRight: Vec2Di{width - 1, height - 1}}
+}
+
+func (geometry Geometry) Validate() error {
+	switch geometry.Kind {
+	case GEOMETRY_PLANE:
+		return nil
+	case GEOMETRY_BOUNDED, GEOMETRY_TORUS:
+		if geometry.UpperRight[0] < geometry.LowerLeft[0] || geometry.UpperRight[1] < geometry.LowerLeft[1] {
+			return errors.New("the rectangle of the geometry is empty")
+		}
+		return nil
+	}
+	return errors.New("unknown geometry " + geometry.Kind)
+}
+
+func (geometry Geometry) IsEqualTo(otherGeometry Geometry) bool {
+	if geometry.Kind == GEOMETRY_PLANE {
+		return otherGeometry.Kind == GEOMETRY_PLANE
+	}
+	return geometry == otherGeometry
+}
+
+func (geometry Geometry) inRectangle(pos Vec2Di) bool {
+	return pos[0] >= geometry.LowerLeft[0] && pos[0] <= geometry.UpperRight[0] && pos[1] >= geometry.LowerLeft[1] && pos[1] <= geometry.UpperRight[1]
+}
+
+// Whether growth can happen at the position
+func (geometry Geometry) Contains(pos Vec2Di) bool {
+	if geometry.Kind == GEOMETRY_PLANE {
+		return true
+	}
+	return geometry.inRectangle(pos)
+}
+
+func positiveModulo(a int, b int) int {
+	return ((a % b) + b) % b
+}
+
+// Returns the position in the fundamental rectangle of a torus, the position itself otherwise
+func (geometry Geometry) Normalize(pos Vec2Di) Vec2Di {
+	if geometry.Kind != GEOMETRY_TORUS {
+		return pos
+	}
+	for i := 0; i < 2; i += 1 {
+		size := geometry.UpperRight[i] - geometry.LowerLeft[i] + 1
+		pos[i] = geometry.LowerLeft[i] + positiveModulo(pos[i]-geometry.LowerLeft[i], size)
+	}
+	return pos
+}
+
+// Returns an error if the geometry cannot hold the lattice: wrapping around a torus must
+// keep the orientation of triangles
+func (geometry Geometry) checkLattice(lattice Lattice) error {
+	if geometry.Kind != GEOMETRY_TORUS || lattice != LATTICE_TRIANGULAR {
+		return nil
+	}
+	for i := 0; i < 2; i += 1 {
+		if (geometry.UpperRight[i]-geometry.LowerLeft[i]+1)%2 != 0 {
+			return errors.New("the width and height of a torus of triangles must be even")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package tamtam
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func growUntilTerminal(t *testing.T, assembly *TileAssembly) {
+	didGrow, err := assembly.GrowSync(true)
+
+	for didGrow && err == nil {
+		didGrow, err = assembly.GrowSync(true)
+	}
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+}
+
+// The Sierpinski system grows forever on the plane and fills the box in a bounded geometry
+func TestBoundedGeometry(t *testing.T) {
+	system, err := LoadTASSystem(filepath.Join("testdata", "sierpinski.tdp"))
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	system.Geometry = &Geometry{Kind: GEOMETRY_BOUNDED, UpperRight: Vec2Di{7, 5}}
+	assembly, err := system.NewAssembly()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+	growUntilTerminal(t, &assembly)
+
+	if assembly.Size() != 8*6 {
+		t.Fatalf(`Assembly size %d != %d`, assembly.Size(), 8*6)
+	}
+
+	if lowerLeft, upperRight := assembly.GetTileMap().BoundingBox(); lowerLeft != (Vec2Di{0, 0}) || upperRight != (Vec2Di{7, 5}) {
+		t.Fatalf(`Assembly grew outside of the box: %v %v`, lowerLeft, upperRight)
+	}
+
+	// The geometry is kept by the JSON encoding
+	b, err := json.Marshal(assembly)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	var decoded TileAssembly
+
+	if err := json.Unmarshal(b, &decoded); err != nil || !decoded.IsEqualTo(assembly) {
+		t.Fatalf(`Decoded assembly differs, %v`, err)
+	}
+}
+
+func TestTorusGeometry(t *testing.T) {
+	tileSet := TileSet{"a": {"x", "x", "x", "x"}}
+	seed := map[Vec2Di]SquareGlues{{5, -1}: tileSet["a"]}
+
+	assembly := NewAssembly(tileSet, seed, 1)
+
+	if err := assembly.SetGeometry(NewTorusGeometry(4, 3)); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if _, ok := assembly.GetTileMap()[Vec2Di{1, 2}]; !ok {
+		t.Fatalf(`Seed was not wrapped into the torus: %v`, assembly.GetTileMap())
+	}
+
+	growUntilTerminal(t, &assembly)
+
+	if assembly.Size() != 12 {
+		t.Fatalf(`Assembly size %d != 12`, assembly.Size())
+	}
+
+	// Every tile has four neighbors, including across the edges
+	if stats := assembly.Statistics(); stats.Mismatches != 0 || assembly.neighboringGlues(Vec2Di{3, 0}) != tileSet["a"] {
+		t.Fatalf(`Unexpected neighbors on the torus`)
+	}
+
+	if err := assembly.SetGeometry(Geometry{Kind: "sphere"}); err == nil {
+		t.Fatalf(`Expected an error for an unknown geometry`)
+	}
+}
@@ -0,0 +1,116 @@
+package tamtam
+
+import "errors"
+
+// Rules of growth beyond glue matching, shared by tile systems, the JSON encoding of assemblies
+// and checkpoints. The zero value is the abstract tile assembly model: square tiles in fixed
+// orientations growing on the plane, without signal tiles, with the same concentration and
+// without detachment.
+type GrowthModel struct {
+	// The infinite plane if nil
+	Geometry *Geometry `json:"geometry,omitempty"`
+	// The square lattice if empty
+	Lattice Lattice `json:"lattice,omitempty"`
+	// Fixed orientations if empty
+	Orientations  Orientations  `json:"orientations,omitempty"`
+	SignalTileSet SignalTileSet `json:"signal_tile_set,omitempty"`
+	// Concentration 1 for tile types that are not listed
+	TileConcentrations TileConcentrations `json:"tile_concentrations,omitempty"`
+	// Tiles never detach if empty
+	Detachment Detachment `json:"detachment,omitempty"`
+}
+
+func (model GrowthModel) GetGeometry() (geometry Geometry) {
+	if model.Geometry != nil {
+		geometry = *model.Geometry
+	}
+	return geometry
+}
+
+func (model GrowthModel) IsEqualTo(otherModel GrowthModel) bool {
+	return model.GetGeometry().IsEqualTo(otherModel.GetGeometry()) && model.Lattice == otherModel.Lattice && model.Orientations == otherModel.Orientations && model.SignalTileSet.IsEqualTo(otherModel.SignalTileSet) && model.TileConcentrations.IsEqualTo(otherModel.TileConcentrations) && model.Detachment == otherModel.Detachment
+}
+
+// Checks that the lattice, the orientations and the geometry are known and fit together,
+// that the tiles fit the lattice and that concentrations are positive
+func (model GrowthModel) validate(tileSet TileSet, tiles TileMap) error {
+	if err := model.Lattice.Validate(); err != nil {
+		return err
+	}
+
+	if err := model.Lattice.checkTiles(tileSet, tiles); err != nil {
+		return err
+	}
+
+	if err := model.Orientations.Validate(); err != nil {
+		return err
+	}
+
+	if model.Lattice != LATTICE_SQUARE && model.Orientations != ORIENTATIONS_FIXED {
+		return errors.New("only square tiles can be rotated")
+	}
+
+	if model.Orientations != ORIENTATIONS_FIXED && len(model.SignalTileSet) > 0 {
+		return errors.New("signal tiles cannot be rotated")
+	}
+
+	if err := model.Orientations.checkTileNames(tileSet); err != nil {
+		return err
+	}
+
+	for name, tileType := range model.SignalTileSet {
+		if err := tileType.validate(name, model.Lattice); err != nil {
+			return err
+		}
+	}
+
+	if err := model.TileConcentrations.validate(tileSet, model.SignalTileSet); err != nil {
+		return err
+	}
+
+	if err := model.Detachment.Validate(); err != nil {
+		return err
+	}
+
+	geometry := model.GetGeometry()
+
+	if err := geometry.Validate(); err != nil {
+		return err
+	}
+
+	return geometry.checkLattice(model.Lattice)
+}
+
+// Sets every rule of the growth model, meant to be called on a new assembly before growth.
+// Nothing is changed if the model is invalid.
+func (assembly *TileAssembly) SetGrowthModel(model GrowthModel) error {
+	if err := model.validate(assembly.TileSet, assembly.tileMap); err != nil {
+		return err
+	}
+
+	if err := assembly.SetLattice(model.Lattice); err != nil {
+		return err
+	}
+
+	if err := assembly.SetOrientations(model.Orientations); err != nil {
+		return err
+	}
+
+	if err := assembly.SetSignalTileSet(model.SignalTileSet); err != nil {
+		return err
+	}
+
+	if err := assembly.SetTileConcentrations(model.TileConcentrations); err != nil {
+		return err
+	}
+
+	if err := assembly.SetDetachment(model.Detachment); err != nil {
+		return err
+	}
+
+	return assembly.SetGeometry(model.GetGeometry())
+}
+
+func (assembly TileAssembly) GetGrowthModel() GrowthModel {
+	return GrowthModel{Geometry: assembly.encodedGeometry(), Lattice: assembly.lattice, Orientations: assembly.orientations, SignalTileSet: assembly.signalTileSet, TileConcentrations: assembly.concentrations, Detachment: assembly.detachment}
+}
@@ -0,0 +1,58 @@
+package tamtam
+
+import "testing"
+
+func TestSetGrowthModel(t *testing.T) {
+	tile := Glues{"a", "a", "a", "a", "a", "a"}
+	model := GrowthModel{
+		Geometry:           &Geometry{Kind: GEOMETRY_TORUS, UpperRight: Vec2Di{3, 3}},
+		Lattice:            LATTICE_HEXAGONAL,
+		TileConcentrations: TileConcentrations{"h": 2},
+		Detachment:         DETACHMENT_UNSTABLE,
+	}
+
+	assembly := NewAssembly(TileSet{"h": tile}, TileMap{{4, 0}: tile}, 2)
+
+	if err := assembly.SetGrowthModel(model); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !assembly.GetGrowthModel().IsEqualTo(model) || assembly.GetTileMap()[Vec2Di{0, 0}] != tile {
+		t.Fatalf(`Growth model %+v instead of %+v`, assembly.GetGrowthModel(), model)
+	}
+
+	// Hexagonal tiles cannot be rotated, nothing changes
+	assembly = NewAssembly(TileSet{"h": tile}, TileMap{{4, 0}: tile}, 2)
+	model.Orientations = ORIENTATIONS_ROTATIONS
+
+	if err := assembly.SetGrowthModel(model); err == nil {
+		t.Fatalf(`Rotatable hexagonal tiles were accepted`)
+	}
+
+	if !assembly.GetGrowthModel().IsEqualTo(GrowthModel{}) {
+		t.Fatalf(`An invalid growth model was partly set: %+v`, assembly.GetGrowthModel())
+	}
+}
+
+func TestNewAssemblyErrors(t *testing.T) {
+	tile := SquareGlues{"a", "a", "a", "a"}
+
+	for _, model := range []GrowthModel{
+		{Geometry: &Geometry{Kind: "sphere"}},
+		{Lattice: LATTICE_HEXAGONAL, Orientations: ORIENTATIONS_ROTATIONS},
+		{Detachment: "sometimes"},
+		{TileConcentrations: TileConcentrations{"b": 1}},
+	} {
+		system := TileSystem{TileSet: TileSet{"a": tile}, InitialTiles: TileMap{{0, 0}: tile}, Threshold: 1, GrowthModel: model}
+
+		if _, err := system.NewAssembly(); err == nil {
+			t.Fatalf(`No error for the growth model %+v`, model)
+		}
+	}
+
+	system := TileSystem{TileSet: TileSet{"a": tile}, InitialTiles: TileMap{{0, 0}: tile}, Threshold: 1, SignalStates: []PosAndSignalState{{Pos: Vec2Di{0, 0}, State: SignalState{Type: "s"}}}}
+
+	if _, err := system.NewAssembly(); err == nil {
+		t.Fatalf(`No error for the state of an unknown signal tile`)
+	}
+}
@@ -0,0 +1,157 @@
+package tamtam
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Largest number of sides of a tile, the one of hexagonal tiles
+const MAX_SIDES = 6
+
+// Arrangement of the tiles of an assembly, which gives the number of sides of the tiles
+// and the neighbors of each position
+type Lattice string
+
+const (
+	// Square tiles, sides are north, east, south and west. The default.
+	LATTICE_SQUARE Lattice = ""
+	// Hexagonal tiles in axial coordinates: [x, y] is the hexagon x + y/2 hexagons to the
+	// east and y rows to the north of the origin. Sides are north east, east, south east,
+	// south west, west and north west.
+	LATTICE_HEXAGONAL Lattice = "hexagonal"
+	// Triangular tiles: the triangle at [x, y] points up when x + y is even, down otherwise.
+	// Sides are the horizontal one (south for triangles pointing up, north otherwise),
+	// east and west.
+	LATTICE_TRIANGULAR Lattice = "triangular"
+)
+
+var hexagonalOffsets = [6]Vec2Di{{0, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, 0}, {-1, 1}}
+
+func (lattice Lattice) Validate() error {
+	switch lattice {
+	case LATTICE_SQUARE, LATTICE_HEXAGONAL, LATTICE_TRIANGULAR:
+		return nil
+	}
+	return errors.New("unknown lattice " + string(lattice))
+}
+
+// Number of sides of the tiles, the glues of the other sides are always null
+func (lattice Lattice) Sides() int {
+	switch lattice {
+	case LATTICE_HEXAGONAL:
+		return 6
+	case LATTICE_TRIANGULAR:
+		return 3
+	}
+	return 4
+}
+
+// Side of the neighbor which touches the given side of a tile
+func (lattice Lattice) Opposite(side int) int {
+	switch lattice {
+	case LATTICE_HEXAGONAL:
+		return (side + 3) % 6
+	case LATTICE_TRIANGULAR:
+		// The horizontal side touches the horizontal side, east touches west
+		return [3]int{0, 2, 1}[side]
+	}
+	return (side + 2) % 4
+}
+
+func (lattice Lattice) SideNames() []string {
+	switch lattice {
+	case LATTICE_HEXAGONAL:
+		return []string{"north east", "east", "south east", "south west", "west", "north west"}
+	case LATTICE_TRIANGULAR:
+		return []string{"horizontal", "east", "west"}
+	}
+	return []string{"north", "east", "south", "west"}
+}
+
+// Returns the neighbor of the position on each side, only the first Sides() neighbors are set
+func (lattice Lattice) Neighbors(pos Vec2Di) (neighbors [MAX_SIDES]Vec2Di) {
+	switch lattice {
+	case LATTICE_HEXAGONAL:
+		for side, offset := range hexagonalOffsets {
+			neighbors[side] = pos.Add(offset)
+		}
+	case LATTICE_TRIANGULAR:
+		if (pos[0]+pos[1])%2 == 0 {
+			neighbors[0] = pos.Add(South)
+		} else {
+			neighbors[0] = pos.Add(North)
+		}
+		neighbors[1] = pos.Add(East)
+		neighbors[2] = pos.Add(West)
+	default:
+		for side, cardinalPoint := range CardinalPoints {
+			neighbors[side] = pos.Add(cardinalPoint)
+		}
+	}
+	return neighbors
+}
+
+func (lattice Lattice) String() string {
+	if lattice == LATTICE_SQUARE {
+		return "square"
+	}
+	return string(lattice)
+}
+
+// Whether the tile has no glue on sides the tiles of the lattice do not have
+func (lattice Lattice) fits(tile Glues) bool {
+	for side := lattice.Sides(); side < MAX_SIDES; side += 1 {
+		if tile[side] != NULL_GLUE {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns an error if a tile of the tile set or of the tile map does not fit the lattice
+func (lattice Lattice) checkTiles(tileSet TileSet, tiles TileMap) error {
+	for _, name := range tileSet.SortedNames() {
+		if !lattice.fits(tileSet[name]) {
+			return fmt.Errorf("tile %q has glues on sides that %s tiles do not have", name, lattice)
+		}
+	}
+	for _, pos := range tiles.SortedPositions() {
+		if !lattice.fits(tiles[pos]) {
+			return fmt.Errorf("the tile at %v has glues on sides that %s tiles do not have", pos, lattice)
+		}
+	}
+	return nil
+}
+
+// Number of glues written in encodings: 4 for tiles of up to 4 sides, MAX_SIDES otherwise,
+// so that square tiles keep their original encoding
+func (glues Glues) encodedSides() int {
+	for side := 4; side < MAX_SIDES; side += 1 {
+		if glues[side] != NULL_GLUE {
+			return MAX_SIDES
+		}
+	}
+	return 4
+}
+
+func (glues Glues) MarshalJSON() ([]byte, error) {
+	return json.Marshal(glues[:glues.encodedSides()])
+}
+
+func (glues *Glues) UnmarshalJSON(b []byte) error {
+	var decoded []string
+
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+
+	if len(decoded) > MAX_SIDES {
+		return errors.New("a tile has more than 6 glues")
+	}
+
+	*glues = Glues{}
+	copy(glues[:], decoded)
+
+	return nil
+}
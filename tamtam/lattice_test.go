@@ -0,0 +1,156 @@
+package tamtam
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLatticeOpposites(t *testing.T) {
+	for _, lattice := range []Lattice{LATTICE_SQUARE, LATTICE_HEXAGONAL, LATTICE_TRIANGULAR} {
+		for _, pos := range []Vec2Di{{0, 0}, {1, 0}, {-3, 2}} {
+			neighbors := lattice.Neighbors(pos)
+			for side := 0; side < lattice.Sides(); side += 1 {
+				if back := lattice.Neighbors(neighbors[side])[lattice.Opposite(side)]; back != pos {
+					t.Fatalf(`%s lattice: the neighbor of %v on side %d leads back to %v`, lattice, pos, side, back)
+				}
+			}
+		}
+	}
+}
+
+func newHexagonalAssembly(t *testing.T) TileAssembly {
+	tile := Glues{"a", "a", "a", "a", "a", "a"}
+	system := TileSystem{
+		TileSet:      TileSet{"h": tile},
+		InitialTiles: TileMap{{0, 0}: tile, {1, 0}: tile},
+		Threshold:    2,
+		GrowthModel:  GrowthModel{Geometry: &Geometry{Kind: GEOMETRY_BOUNDED, UpperRight: Vec2Di{3, 3}}, Lattice: LATTICE_HEXAGONAL},
+	}
+
+	assembly, err := system.NewAssembly()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	return assembly
+}
+
+// Two adjacent hexagons are enough to cooperatively fill a parallelogram at threshold 2,
+// which square tiles cannot do
+func TestHexagonalGrowth(t *testing.T) {
+	assembly := newHexagonalAssembly(t)
+	growUntilTerminal(t, &assembly)
+
+	if assembly.Size() != 16 {
+		t.Fatalf(`Assembly size %d != 16`, assembly.Size())
+	}
+
+	if stats := assembly.Statistics(); stats.Mismatches != 0 || stats.Holes != 0 {
+		t.Fatalf(`Unexpected statistics %+v`, stats)
+	}
+
+	b, err := json.Marshal(assembly)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !strings.Contains(string(b), `"lattice":"hexagonal"`) || !strings.Contains(string(b), `["a","a","a","a","a","a"]`) {
+		t.Fatalf(`Unexpected JSON encoding %s`, b)
+	}
+
+	var decoded TileAssembly
+
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !decoded.IsEqualTo(assembly) {
+		t.Fatalf(`Decoded assembly differs from the original one`)
+	}
+
+	var square TileAssembly
+
+	if err := json.Unmarshal(bytes.Replace(b, []byte(`"lattice":"hexagonal"`), []byte(`"lattice":""`), 1), &square); err == nil {
+		t.Fatalf(`Hexagonal tiles accepted on the square lattice`)
+	}
+}
+
+func TestTriangularGrowth(t *testing.T) {
+	tile := Glues{"a", "b", "b"}
+	assembly := NewAssembly(TileSet{"t": tile}, TileMap{{0, 0}: tile}, 1)
+
+	if err := assembly.SetLattice(LATTICE_TRIANGULAR); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if err := assembly.SetGeometry(NewTorusGeometry(3, 2)); err == nil {
+		t.Fatalf(`Torus of odd width accepted for triangles`)
+	}
+
+	if err := assembly.SetGeometry(NewTorusGeometry(4, 2)); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	growUntilTerminal(t, &assembly)
+
+	if assembly.Size() != 8 {
+		t.Fatalf(`Assembly size %d != 8`, assembly.Size())
+	}
+
+	if stats := assembly.Statistics(); stats.Mismatches != 0 {
+		t.Fatalf(`%d mismatches`, stats.Mismatches)
+	}
+}
+
+func TestValidateOnLattice(t *testing.T) {
+	tileSet := TileSet{"a": Glues{"", "x", "", "", "", ""}, "b": Glues{"", "", "", "", "", "y"}}
+
+	errs, warnings := tileSet.ValidateOnLattice(LATTICE_HEXAGONAL)
+
+	if len(errs) != 0 {
+		t.Fatalf(`Unexpected errors %v`, errs)
+	}
+
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "does not appear on the west side") || !strings.Contains(warnings[1], "does not appear on the south east side") {
+		t.Fatalf(`Unexpected warnings %q`, warnings)
+	}
+
+	if errs, _ := tileSet.Validate(); len(errs) != 1 {
+		t.Fatalf(`Expected tile "b" not to fit the square lattice, got %v`, errs)
+	}
+}
+
+func TestLatticeSerialization(t *testing.T) {
+	system := newHexagonalAssembly(t).GetTileSystem()
+
+	var buffer bytes.Buffer
+
+	// The binary format holds the lattice but not the geometry
+	if err := WriteTileSystemBinary(&buffer, system); err == nil {
+		t.Fatalf(`Bounded geometry written in the binary format`)
+	}
+
+	system.Geometry = nil
+
+	if err := WriteTileSystemBinary(&buffer, system); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	decoded, err := ReadTileSystemBinary(&buffer)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !decoded.IsEqualTo(system) {
+		t.Fatalf(`Decoded tile system differs from the original one`)
+	}
+
+	if err := WriteTileSystemDSL(&buffer, system); err == nil {
+		t.Fatalf(`Hexagonal tiles written in the tile system description language`)
+	}
+}
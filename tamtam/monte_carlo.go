@@ -0,0 +1,124 @@
+package tamtam
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// Distribution of the assemblies produced by many seeded runs of asynchronous,
+// non directed growth of a tile system
+type MonteCarloResult struct {
+	Runs int `json:"runs"`
+	// Runs stopped by the step limit before their assembly was terminal
+	Unfinished int `json:"unfinished"`
+	// Number of runs ending with each assembly size
+	SizeCounts map[int]int `json:"size_counts"`
+	MeanSize   float64     `json:"mean_size"`
+	// Distinct sets of positions the runs ended with, the most frequent first
+	Shapes []ShapeCount `json:"shapes"`
+}
+
+type ShapeCount struct {
+	// Positions of the tiles in canonical order
+	Positions []Vec2Di `json:"positions"`
+	Runs      int      `json:"runs"`
+}
+
+// Grows the tile system asynchronously the given number of times, run i using the random seed
+// seed+i, until the assembly is terminal or after maxSteps steps if maxSteps is positive.
+// Tiles fitting the same position are chosen according to the tile concentrations.
+func RunMonteCarlo(system TileSystem, runs int, seed int64, maxSteps int) (result MonteCarloResult, err error) {
+	if runs <= 0 {
+		return result, errors.New("the number of runs must be positive")
+	}
+
+	result.Runs = runs
+	result.SizeCounts = make(map[int]int)
+	shapeIndices := make(map[string]int)
+	totalSize := 0
+
+	for run := 0; run < runs; run += 1 {
+		assembly, err := system.NewAssembly()
+
+		if err != nil {
+			return result, err
+		}
+
+		assembly.SetRandomSeed(seed + int64(run))
+
+		didGrow := true
+		for didGrow && (maxSteps <= 0 || assembly.GetSteps() < maxSteps) {
+			if didGrow, err = assembly.GrowAsync(false); err != nil {
+				return result, fmt.Errorf("run %d: %v", run, err)
+			}
+		}
+
+		// The last step allowed may have made the assembly terminal
+		if didGrow && assembly.canGrow() {
+			result.Unfinished += 1
+		}
+
+		result.SizeCounts[assembly.Size()] += 1
+		totalSize += assembly.Size()
+
+		positions := assembly.GetTileMap().SortedPositions()
+		key := fmt.Sprint(positions)
+
+		if index, ok := shapeIndices[key]; ok {
+			result.Shapes[index].Runs += 1
+		} else {
+			shapeIndices[key] = len(result.Shapes)
+			result.Shapes = append(result.Shapes, ShapeCount{Positions: positions, Runs: 1})
+		}
+	}
+
+	result.MeanSize = float64(totalSize) / float64(runs)
+
+	// Shapes seen as often keep the order of the runs that first produced them
+	sort.SliceStable(result.Shapes, func(i, j int) bool {
+		return result.Shapes[i].Runs > result.Shapes[j].Runs
+	})
+
+	return result, nil
+}
+
+// Prints the size distribution and the most frequent shapes as tables
+func (result MonteCarloResult) WriteTable(w io.Writer, maxShapes int) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Runs\t%d\n", result.Runs)
+	fmt.Fprintf(tw, "Unfinished runs\t%d\n", result.Unfinished)
+	fmt.Fprintf(tw, "Mean size\t%.2f\n", result.MeanSize)
+	fmt.Fprintf(tw, "Distinct shapes\t%d\n", len(result.Shapes))
+
+	sizes := make([]int, 0, len(result.SizeCounts))
+	for size := range result.SizeCounts {
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+
+	fmt.Fprintln(tw, "\nSize\tRuns")
+	for _, size := range sizes {
+		fmt.Fprintf(tw, "%d\t%d\n", size, result.SizeCounts[size])
+	}
+
+	fmt.Fprintln(tw, "\nShape\tRuns\tSize\tBounding box")
+	for i, shape := range result.Shapes {
+		if i == maxShapes {
+			break
+		}
+
+		tileMap := make(TileMap)
+		for _, pos := range shape.Positions {
+			tileMap[pos] = Glues{}
+		}
+		lowerLeft, upperRight := tileMap.BoundingBox()
+
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%v to %v\n", i+1, shape.Runs, len(shape.Positions), lowerLeft, upperRight)
+	}
+
+	return tw.Flush()
+}
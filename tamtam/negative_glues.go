@@ -0,0 +1,153 @@
+package tamtam
+
+// Negative glues: glues of negative strength lower the binding strength of the tiles they
+// match. A tile attaches when its total binding strength, repulsions included, reaches the
+// threshold, but a neighbor attaching later can bring a repulsion that makes it unstable:
+// its binding strength falls below the threshold while its attractions alone reach it.
+//
+// In the irreversible model (the default) unstable tiles stay in place and are only reported
+// to observers. With DETACHMENT_UNSTABLE they detach as soon as they become unstable, and so
+// do the tiles placed by growth whose binding strength a detachment brings below the
+// threshold (initial tiles never do). Each detachment can make more tiles detach, and growth
+// may then never end.
+
+import "errors"
+
+// What happens to tiles that become unstable
+type Detachment string
+
+const (
+	// Tiles never detach, the default
+	DETACHMENT_NONE Detachment = ""
+	// Tiles made unstable by repulsions detach
+	DETACHMENT_UNSTABLE Detachment = "unstable"
+)
+
+func (detachment Detachment) Validate() error {
+	switch detachment {
+	case DETACHMENT_NONE, DETACHMENT_UNSTABLE:
+		return nil
+	}
+	return errors.New("unknown detachment " + string(detachment))
+}
+
+// Whether some glue has a negative strength
+func (strengths GlueStrengths) hasRepulsion() bool {
+	for _, strength := range strengths {
+		if strength < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (assembly *TileAssembly) SetDetachment(detachment Detachment) error {
+	if err := detachment.Validate(); err != nil {
+		return err
+	}
+
+	assembly.detachment = detachment
+
+	return nil
+}
+
+func (assembly TileAssembly) GetDetachment() Detachment {
+	return assembly.detachment
+}
+
+// Returns the total strength of the positive glues of the tile at the position matching the
+// glues of its neighbors, and the total strength of all its matching glues, 0 and 0 if there
+// is no tile there
+func (assembly TileAssembly) bindingStrengths(pos Vec2Di) (attraction int, total int) {
+	tile, ok := assembly.tileMap[pos]
+
+	if !ok {
+		return 0, 0
+	}
+
+	for side, glue := range assembly.neighboringGlues(pos) {
+		if glue == NULL_GLUE || glue != tile[side] {
+			continue
+		}
+
+		strength := assembly.glueStrengths.Strength(glue)
+		if strength > 0 {
+			attraction += strength
+		}
+		total += strength
+	}
+
+	return attraction, total
+}
+
+// Returns the total strength of the glues of the tile at the position which match the glues of
+// its neighbors, negative strengths included, 0 if there is no tile there
+func (assembly TileAssembly) BindingStrength(pos Vec2Di) int {
+	_, total := assembly.bindingStrengths(assembly.geometry.Normalize(pos))
+	return total
+}
+
+func (assembly TileAssembly) isUnstable(pos Vec2Di) bool {
+	attraction, total := assembly.bindingStrengths(pos)
+	return attraction >= assembly.threshold && total < assembly.threshold
+}
+
+// Returns the positions, in canonical order, of the tiles whose binding strength is below the
+// threshold because of repulsions, their attractions alone reaching it
+func (assembly TileAssembly) UnstableTiles() (positions []Vec2Di) {
+	for _, pos := range assembly.tileMap.SortedPositions() {
+		if assembly.isUnstable(pos) {
+			positions = append(positions, pos)
+		}
+	}
+	return positions
+}
+
+// Returns the position and its neighbors
+func (assembly TileAssembly) around(pos Vec2Di) []Vec2Di {
+	neighbors := assembly.neighbors(pos)
+	return append([]Vec2Di{pos}, neighbors[:assembly.lattice.Sides()]...)
+}
+
+// Returns the unstable tiles among the tile at the position and its neighbors
+func (assembly TileAssembly) unstableAround(pos Vec2Di) map[Vec2Di]bool {
+	unstable := make(map[Vec2Di]bool)
+	for _, nei := range assembly.around(pos) {
+		if assembly.isUnstable(nei) {
+			unstable[nei] = true
+		}
+	}
+	return unstable
+}
+
+// Reports the tiles around the position that became unstable since wasUnstable was computed
+// by unstableAround, and detaches them if the model says so
+func (assembly *TileAssembly) checkStability(pos Vec2Di, wasUnstable map[Vec2Di]bool) {
+	for _, nei := range assembly.around(pos) {
+		if wasUnstable[nei] || !assembly.isUnstable(nei) {
+			continue
+		}
+
+		wasUnstable[nei] = true
+		assembly.notifyUnstableTile(nei, assembly.tileMap[nei])
+
+		if assembly.detachment == DETACHMENT_UNSTABLE {
+			assembly.detach(nei)
+		}
+	}
+}
+
+// Removes the tile, then the tiles it makes unstable and the tiles placed by growth that it
+// leaves below the threshold, and so on
+func (assembly *TileAssembly) detach(pos Vec2Di) {
+	unstable := assembly.unstableAround(pos)
+	assembly.RemoveTile(pos)
+	assembly.checkStability(pos, unstable)
+
+	neighbors := assembly.neighbors(pos)
+	for _, nei := range neighbors[:assembly.lattice.Sides()] {
+		if _, total := assembly.bindingStrengths(nei); assembly.grownTiles[nei] && total < assembly.threshold {
+			assembly.detach(nei)
+		}
+	}
+}
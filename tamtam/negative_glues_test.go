@@ -0,0 +1,250 @@
+package tamtam
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestMatchTilesWithNegativeGlues(t *testing.T) {
+	tileSet := TileSet{
+		"attracted": SquareGlues{"a", "b", NULL_GLUE, NULL_GLUE},
+		"repelled":  SquareGlues{"a", "b", "n", NULL_GLUE},
+	}
+	strengths := GlueStrengths{"a": 2, "n": -1}
+
+	// The mismatching west glue does not stop the matching glues from being counted
+	matches := tileSet.MatchTilesWithStrengths(SquareGlues{"a", "b", "n", "x"}, strengths, 3)
+
+	if len(matches) != 1 || matches[0] != tileSet["attracted"] {
+		t.Fatalf(`Unexpected matches %v`, matches)
+	}
+
+	if matches := tileSet.MatchTilesWithStrengths(SquareGlues{"a", "b", "n", "x"}, strengths, 2); len(matches) != 2 {
+		t.Fatalf(`Unexpected matches %v`, matches)
+	}
+}
+
+// At threshold 2 a row grows east with a strength 2 glue, its tiles having a glue on their
+// north side which a ceiling tile repels: the row stops under the ceiling (repulsion blocking
+// attachment, as in Doty, Kari and Masson, Negative interactions in irreversible self-assembly)
+func TestRepulsionBlocksGrowth(t *testing.T) {
+	tileSet := TileSet{"row": SquareGlues{"n", "r", NULL_GLUE, "r"}}
+	seed := TileMap{
+		Vec2Di{0, 0}: SquareGlues{NULL_GLUE, "r", NULL_GLUE, NULL_GLUE},
+		Vec2Di{2, 1}: SquareGlues{NULL_GLUE, NULL_GLUE, "n", NULL_GLUE},
+	}
+
+	attracting := NewAssemblyWithGlueStrengths(tileSet, GlueStrengths{"r": 2}, seed, 2)
+
+	if err := growToTerminal(&attracting, 5); err == nil {
+		t.Fatalf(`The row stopped`)
+	}
+
+	if _, ok := attracting.GetTileMap()[Vec2Di{5, 0}]; !ok {
+		t.Fatalf(`The row did not go past the ceiling`)
+	}
+
+	repelling := NewAssemblyWithGlueStrengths(tileSet, GlueStrengths{"r": 2, "n": -1}, seed, 2)
+
+	if err := growToTerminal(&repelling, 5); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if repelling.Size() != 3 || repelling.GetSteps() != 1 {
+		t.Fatalf(`The row went on under the ceiling, %d tiles after %d steps`, repelling.Size(), repelling.GetSteps())
+	}
+
+	if repelling.BindingStrength(Vec2Di{1, 0}) != 2 || len(repelling.UnstableTiles()) != 0 {
+		t.Fatalf(`Unexpected binding strength %d`, repelling.BindingStrength(Vec2Di{1, 0}))
+	}
+}
+
+type unstableTilesObserver struct {
+	BaseAssemblyObserver
+	positions []Vec2Di
+}
+
+func (observer *unstableTilesObserver) OnUnstableTile(pos Vec2Di, tile SquareGlues) {
+	observer.positions = append(observer.positions, pos)
+}
+
+// At threshold 1, a tile attached to the seed by a strength 1 glue is repelled by a tile
+// attaching later above it with a strength 2 glue, which breaks the first tile off (the
+// detachment gadget of Patitz, Schweller and Summers, Exact shapes and Turing universality
+// at temperature 1 with a single negative glue)
+func newDetachmentSystem() TileSystem {
+	return TileSystem{
+		TileSet: TileSet{
+			"broken":  SquareGlues{"n", NULL_GLUE, NULL_GLUE, "a"},
+			"column":  SquareGlues{NULL_GLUE, "x", "u", NULL_GLUE},
+			"breaker": SquareGlues{NULL_GLUE, NULL_GLUE, "n", "x"},
+		},
+		GlueStrengths: GlueStrengths{"x": 2, "n": -1},
+		InitialTiles:  TileMap{Vec2Di{0, 0}: SquareGlues{"u", "a", NULL_GLUE, NULL_GLUE}},
+		Threshold:     1,
+	}
+}
+
+func TestUnstableTiles(t *testing.T) {
+	system := newDetachmentSystem()
+	assembly, err := system.NewAssembly()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+	observer := &unstableTilesObserver{}
+	assembly.AddObserver(observer)
+
+	if err := growToTerminal(&assembly, 10); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if assembly.Size() != 4 {
+		t.Fatalf(`Irreversible growth ended with %d tiles`, assembly.Size())
+	}
+
+	if unstable := assembly.UnstableTiles(); len(unstable) != 1 || unstable[0] != (Vec2Di{1, 0}) {
+		t.Fatalf(`Unexpected unstable tiles %v`, unstable)
+	}
+
+	if len(observer.positions) != 1 || observer.positions[0] != (Vec2Di{1, 0}) || assembly.Statistics().Unstable != 1 {
+		t.Fatalf(`Observer saw unstable tiles %v`, observer.positions)
+	}
+
+	system.Detachment = DETACHMENT_UNSTABLE
+	assembly, err = system.NewAssembly()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+	observer = &unstableTilesObserver{}
+	assembly.AddObserver(observer)
+
+	if err := growToTerminal(&assembly, 10); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if _, ok := assembly.GetTileMap()[Vec2Di{1, 0}]; ok || assembly.Size() != 3 || len(observer.positions) != 1 {
+		t.Fatalf(`The unstable tile did not detach for good, %d tiles`, assembly.Size())
+	}
+
+	if len(assembly.UnstableTiles()) != 0 || assembly.BindingStrength(Vec2Di{1, 1}) != 2 {
+		t.Fatalf(`Unexpected unstable tiles after detachment %v`, assembly.UnstableTiles())
+	}
+
+	b, err := json.Marshal(assembly)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	var decoded TileAssembly
+
+	if err := json.Unmarshal(b, &decoded); err != nil || !decoded.IsEqualTo(assembly) || decoded.GetDetachment() != DETACHMENT_UNSTABLE {
+		t.Fatalf(`Decoded assembly differs from the original one: %v`, err)
+	}
+
+	system.Detachment = "sometimes"
+
+	if err := system.Validate(); err == nil {
+		t.Fatalf(`Unknown detachment accepted`)
+	}
+}
+
+// At threshold 2 a row of two tiles grows east of the seed while a hook grows north of it,
+// and the ceiling tile hanging from the hook repels the first tile of the row. With detachment
+// the first tile detaches, leaving the second one with no binding: it detaches too.
+func TestDetachmentOrphansTiles(t *testing.T) {
+	system := TileSystem{
+		TileSet: TileSet{
+			"first":   SquareGlues{"r", "a", NULL_GLUE, "s"},
+			"second":  SquareGlues{NULL_GLUE, NULL_GLUE, NULL_GLUE, "a"},
+			"column":  SquareGlues{"d", NULL_GLUE, "c", NULL_GLUE},
+			"top":     SquareGlues{NULL_GLUE, "e", "d", NULL_GLUE},
+			"hook":    SquareGlues{NULL_GLUE, NULL_GLUE, "k", "e"},
+			"ceiling": SquareGlues{"k", NULL_GLUE, "r", NULL_GLUE},
+		},
+		GlueStrengths: GlueStrengths{"s": 2, "a": 2, "c": 2, "d": 2, "e": 2, "k": 5, "r": -3},
+		InitialTiles:  TileMap{Vec2Di{0, 0}: SquareGlues{"c", "s", NULL_GLUE, NULL_GLUE}},
+		Threshold:     2,
+	}
+
+	assembly, err := system.NewAssembly()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if err := growToTerminal(&assembly, 10); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if assembly.Size() != 7 || assembly.BindingStrength(Vec2Di{2, 0}) != 2 {
+		t.Fatalf(`Irreversible growth ended with %d tiles`, assembly.Size())
+	}
+
+	system.Detachment = DETACHMENT_UNSTABLE
+	assembly, err = system.NewAssembly()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+	observer := &unstableTilesObserver{}
+	assembly.AddObserver(observer)
+
+	if err := growToTerminal(&assembly, 10); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	tileMap := assembly.GetTileMap()
+	_, first := tileMap[Vec2Di{1, 0}]
+	_, second := tileMap[Vec2Di{2, 0}]
+
+	if first || second || assembly.Size() != 5 {
+		t.Fatalf(`The row did not detach, %d tiles`, assembly.Size())
+	}
+
+	if len(observer.positions) != 1 || observer.positions[0] != (Vec2Di{1, 0}) || len(assembly.UnstableTiles()) != 0 {
+		t.Fatalf(`Observer saw unstable tiles %v`, observer.positions)
+	}
+
+	// The seed is left in place even though nothing binds it anymore on its east side
+	if _, ok := tileMap[Vec2Di{0, 0}]; !ok {
+		t.Fatalf(`The seed detached`)
+	}
+
+	// Growth resumed from a checkpoint taken once the row is complete still tells grown tiles
+	// from initial ones
+	assembly, err = system.NewAssembly()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	for step := 0; step < 2; step += 1 {
+		if _, err := assembly.GrowSync(true); err != nil {
+			t.Fatalf(`%v`, err)
+		}
+	}
+
+	var b bytes.Buffer
+
+	if err := assembly.WriteCheckpoint(&b); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	resumed, err := ReadCheckpoint(&b)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if err := growToTerminal(&resumed, 10); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if resumed.Size() != 5 {
+		t.Fatalf(`The row did not detach after resuming, %d tiles`, resumed.Size())
+	}
+}
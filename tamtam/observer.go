@@ -0,0 +1,135 @@
+package tamtam
+
+// Receives the changes of an assembly as they happen, see TileAssembly.AddObserver
+type AssemblyObserver interface {
+	OnTileAdded(pos Vec2Di, tile SquareGlues)
+	OnTileRemoved(pos Vec2Di, tile SquareGlues)
+	// Called after each call to GrowSync or GrowAsync that added tiles, step is the new value of GetSteps
+	OnStepComplete(step int)
+	// Called when several tile types fit the same position during growth, which makes growth fail in the directed setting
+	OnConflict(pos Vec2Di, tiles []SquareGlues)
+	// Called when a tile placed by growth makes a tile unstable, see negative_glues.go. With
+	// DETACHMENT_UNSTABLE the tile is then removed.
+	OnUnstableTile(pos Vec2Di, tile SquareGlues)
+}
+
+// Observer doing nothing, to embed in observers only interested in some of the events
+type BaseAssemblyObserver struct{}
+
+func (BaseAssemblyObserver) OnTileAdded(pos Vec2Di, tile SquareGlues)    {}
+func (BaseAssemblyObserver) OnTileRemoved(pos Vec2Di, tile SquareGlues)  {}
+func (BaseAssemblyObserver) OnStepComplete(step int)                     {}
+func (BaseAssemblyObserver) OnConflict(pos Vec2Di, tiles []SquareGlues)  {}
+func (BaseAssemblyObserver) OnUnstableTile(pos Vec2Di, tile SquareGlues) {}
+
+// Observer keeping the tiles added and removed since its last flush.
+// Consumers should process removed tiles before added ones as a position
+// can be removed and then filled again.
+type ChangeLog struct {
+	BaseAssemblyObserver
+	added []PosAndTile
+	// Indices in added of the tiles added at each position, so that removals take constant time
+	addedIndices map[Vec2Di][]int
+	// Indices in added of the tiles removed since they were added
+	removedIndices map[int]bool
+	removed        []PosAndTile
+}
+
+func NewChangeLog() *ChangeLog {
+	return &ChangeLog{}
+}
+
+func (log *ChangeLog) OnTileAdded(pos Vec2Di, tile SquareGlues) {
+	if log.addedIndices == nil {
+		log.addedIndices = make(map[Vec2Di][]int)
+	}
+	log.addedIndices[pos] = append(log.addedIndices[pos], len(log.added))
+	log.added = append(log.added, PosAndTile{Pos: pos, Tile: tile})
+}
+
+func (log *ChangeLog) OnTileRemoved(pos Vec2Di, tile SquareGlues) {
+	// A tile that was added and removed since the last flush is never reported as added
+	if indices, ok := log.addedIndices[pos]; ok {
+		if log.removedIndices == nil {
+			log.removedIndices = make(map[int]bool)
+		}
+		for _, index := range indices {
+			log.removedIndices[index] = true
+		}
+		delete(log.addedIndices, pos)
+	}
+	log.removed = append(log.removed, PosAndTile{Pos: pos, Tile: tile})
+}
+
+func (log ChangeLog) GetAddedTiles() []PosAndTile {
+	if len(log.removedIndices) == 0 {
+		return log.added
+	}
+
+	added := make([]PosAndTile, 0, len(log.added)-len(log.removedIndices))
+	for i, posAndTile := range log.added {
+		if !log.removedIndices[i] {
+			added = append(added, posAndTile)
+		}
+	}
+	return added
+}
+
+func (log ChangeLog) GetRemovedTiles() []PosAndTile {
+	return log.removed
+}
+
+func (log *ChangeLog) FlushAddedTiles() {
+	log.added = []PosAndTile{}
+	log.addedIndices = nil
+	log.removedIndices = nil
+}
+
+func (log *ChangeLog) FlushRemovedTiles() {
+	log.removed = []PosAndTile{}
+}
+
+// Registers an observer, observers are notified in the order they were added.
+// Observers are compared by RemoveObserver and are therefore usually pointers.
+func (assembly *TileAssembly) AddObserver(observer AssemblyObserver) {
+	assembly.observers = append(assembly.observers, observer)
+}
+
+func (assembly *TileAssembly) RemoveObserver(observer AssemblyObserver) {
+	for i, registered := range assembly.observers {
+		if registered == observer {
+			assembly.observers = append(assembly.observers[:i:i], assembly.observers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (assembly *TileAssembly) notifyTileAdded(pos Vec2Di, tile SquareGlues) {
+	for _, observer := range assembly.observers {
+		observer.OnTileAdded(pos, tile)
+	}
+}
+
+func (assembly *TileAssembly) notifyTileRemoved(pos Vec2Di, tile SquareGlues) {
+	for _, observer := range assembly.observers {
+		observer.OnTileRemoved(pos, tile)
+	}
+}
+
+func (assembly *TileAssembly) notifyStepComplete() {
+	for _, observer := range assembly.observers {
+		observer.OnStepComplete(assembly.steps)
+	}
+}
+
+func (assembly *TileAssembly) notifyConflict(pos Vec2Di, tiles []SquareGlues) {
+	for _, observer := range assembly.observers {
+		observer.OnConflict(pos, tiles)
+	}
+}
+
+func (assembly *TileAssembly) notifyUnstableTile(pos Vec2Di, tile SquareGlues) {
+	for _, observer := range assembly.observers {
+		observer.OnUnstableTile(pos, tile)
+	}
+}
@@ -0,0 +1,108 @@
+package tamtam
+
+import (
+	"reflect"
+	"testing"
+)
+
+type countingObserver struct {
+	BaseAssemblyObserver
+	steps     []int
+	conflicts []Vec2Di
+}
+
+func (observer *countingObserver) OnStepComplete(step int) {
+	observer.steps = append(observer.steps, step)
+}
+
+func (observer *countingObserver) OnConflict(pos Vec2Di, tiles []SquareGlues) {
+	observer.conflicts = append(observer.conflicts, pos)
+}
+
+func TestObservers(t *testing.T) {
+	assembly := grownCrtAssembly(t, 6)
+	assembly.ClearRegion(Vec2Di{3, 3}, Vec2Di{5, 5})
+
+	first, second := NewChangeLog(), NewChangeLog()
+	counter := &countingObserver{}
+	assembly.AddObserver(first)
+	assembly.AddObserver(second)
+	assembly.AddObserver(counter)
+
+	assembly.RemoveTile(Vec2Di{2, 5})
+	second.FlushRemovedTiles()
+
+	didGrow, err := assembly.GrowSync(true)
+
+	for didGrow && err == nil {
+		didGrow, err = assembly.GrowSync(true)
+	}
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	// Each change log sees every change, whatever the others do
+	if len(first.GetRemovedTiles()) != 1 || len(second.GetRemovedTiles()) != 0 {
+		t.Fatalf(`Change logs report %d and %d removed tiles instead of 1 and 0`, len(first.GetRemovedTiles()), len(second.GetRemovedTiles()))
+	}
+
+	if len(first.GetAddedTiles()) != 10 || len(second.GetAddedTiles()) != 10 {
+		t.Fatalf(`Change logs report %d and %d added tiles instead of 10`, len(first.GetAddedTiles()), len(second.GetAddedTiles()))
+	}
+
+	if len(counter.steps) == 0 || counter.steps[len(counter.steps)-1] != assembly.GetSteps() {
+		t.Fatalf(`Steps %v do not end with %d`, counter.steps, assembly.GetSteps())
+	}
+
+	// Two tile types fitting east of the seed
+	tileSet := TileSet{"a": {"", "", "", "x"}, "b": {"y", "", "", "x"}}
+	seed := map[Vec2Di]SquareGlues{{0, 0}: {"", "x", "", ""}}
+	conflicting := NewAssemblyWithGlueStrengths(tileSet, GlueStrengths{"x": 2}, seed, 2)
+	conflicting.AddObserver(counter)
+	conflicting.RemoveObserver(first)
+
+	if _, err := conflicting.GrowSync(true); err == nil {
+		t.Fatalf(`Expected directed growth to fail`)
+	}
+
+	if len(counter.conflicts) != 1 || counter.conflicts[0] != (Vec2Di{1, 0}) {
+		t.Fatalf(`Conflicts %v instead of [1 0]`, counter.conflicts)
+	}
+
+	assembly.RemoveObserver(first)
+	assembly.RemoveTile(Vec2Di{0, 0})
+
+	if len(first.GetRemovedTiles()) != 1 || len(second.GetRemovedTiles()) != 1 {
+		t.Fatalf(`Removed observer was still notified`)
+	}
+}
+
+// Tiles removed after being added are left out of the added tiles, a position filled again is reported again
+func TestChangeLog(t *testing.T) {
+	changes := NewChangeLog()
+	a, b := SquareGlues{"a"}, SquareGlues{"b"}
+
+	changes.OnTileAdded(Vec2Di{0, 0}, a)
+	changes.OnTileAdded(Vec2Di{1, 0}, a)
+	changes.OnTileRemoved(Vec2Di{0, 0}, a)
+	changes.OnTileAdded(Vec2Di{0, 0}, b)
+	changes.OnTileRemoved(Vec2Di{2, 0}, b)
+
+	expected := []PosAndTile{{Vec2Di{1, 0}, a}, {Vec2Di{0, 0}, b}}
+	if !reflect.DeepEqual(changes.GetAddedTiles(), expected) {
+		t.Fatalf(`Added tiles %v instead of %v`, changes.GetAddedTiles(), expected)
+	}
+
+	if len(changes.GetRemovedTiles()) != 2 {
+		t.Fatalf(`%d removed tiles instead of 2`, len(changes.GetRemovedTiles()))
+	}
+
+	changes.FlushAddedTiles()
+	changes.OnTileRemoved(Vec2Di{1, 0}, a)
+	changes.OnTileAdded(Vec2Di{3, 0}, a)
+
+	if len(changes.GetAddedTiles()) != 1 || len(changes.GetRemovedTiles()) != 3 {
+		t.Fatalf(`%d added and %d removed tiles after the flush`, len(changes.GetAddedTiles()), len(changes.GetRemovedTiles()))
+	}
+}
@@ -0,0 +1,94 @@
+package tamtam
+
+// Set of positions that can be iterated in a deterministic order and sampled
+// in constant time, which a map of positions does not allow
+type positionSet struct {
+	positions []Vec2Di
+	indices   map[Vec2Di]int
+}
+
+func newPositionSet() positionSet {
+	return positionSet{indices: make(map[Vec2Di]int)}
+}
+
+func (set *positionSet) Add(pos Vec2Di) {
+	if _, ok := set.indices[pos]; ok {
+		return
+	}
+	set.indices[pos] = len(set.positions)
+	set.positions = append(set.positions, pos)
+}
+
+// Removing by moving the last position in place of the removed one
+func (set *positionSet) Remove(pos Vec2Di) {
+	index, ok := set.indices[pos]
+	if !ok {
+		return
+	}
+	last := set.positions[len(set.positions)-1]
+	set.positions[index] = last
+	set.indices[last] = index
+	set.positions = set.positions[:len(set.positions)-1]
+	delete(set.indices, pos)
+}
+
+func (set positionSet) Contains(pos Vec2Di) bool {
+	_, ok := set.indices[pos]
+	return ok
+}
+
+func (set positionSet) Len() int {
+	return len(set.positions)
+}
+
+func (set positionSet) At(index int) Vec2Di {
+	return set.positions[index]
+}
+
+// Returns a copy of the positions in the order of the set
+func (set positionSet) Positions() []Vec2Di {
+	return append([]Vec2Di{}, set.positions...)
+}
+
+// Same as positionSet for positions in space
+type positionSet3D struct {
+	positions []Vec3Di
+	indices   map[Vec3Di]int
+}
+
+func newPositionSet3D() positionSet3D {
+	return positionSet3D{indices: make(map[Vec3Di]int)}
+}
+
+func (set *positionSet3D) Add(pos Vec3Di) {
+	if _, ok := set.indices[pos]; ok {
+		return
+	}
+	set.indices[pos] = len(set.positions)
+	set.positions = append(set.positions, pos)
+}
+
+func (set *positionSet3D) Remove(pos Vec3Di) {
+	index, ok := set.indices[pos]
+	if !ok {
+		return
+	}
+	last := set.positions[len(set.positions)-1]
+	set.positions[index] = last
+	set.indices[last] = index
+	set.positions = set.positions[:len(set.positions)-1]
+	delete(set.indices, pos)
+}
+
+func (set positionSet3D) Len() int {
+	return len(set.positions)
+}
+
+func (set positionSet3D) At(index int) Vec3Di {
+	return set.positions[index]
+}
+
+// Returns a copy of the positions in the order of the set
+func (set positionSet3D) Positions() []Vec3Di {
+	return append([]Vec3Di{}, set.positions...)
+}
@@ -0,0 +1,27 @@
+package tamtam
+
+// SplitMix64 generator used as the source of the assembly's random numbers. Unlike the
+// sources of math/rand, its whole state is a single integer that checkpoints can save.
+type splitMix64Source struct {
+	state uint64
+}
+
+func newSplitMix64Source(seed int64) *splitMix64Source {
+	return &splitMix64Source{state: uint64(seed)}
+}
+
+func (source *splitMix64Source) Seed(seed int64) {
+	source.state = uint64(seed)
+}
+
+func (source *splitMix64Source) Uint64() uint64 {
+	source.state += 0x9e3779b97f4a7c15
+	z := source.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (source *splitMix64Source) Int63() int64 {
+	return int64(source.Uint64() >> 1)
+}
@@ -0,0 +1,373 @@
+package tamtam
+
+// Target shapes of tile sets and checking that assemblies build them. Shapes are compared up to
+// translation: the lower left corners of the bounding boxes are aligned.
+//
+// Shapes can be read from ASCII art, row by row from north to south, '#' (or 'X', 'x', '*',
+// '1') being a position of the shape and '.' (or ' ', '_', '0') a position outside of it:
+//
+//	#..
+//	##.
+//	###
+//
+// or from PBM images (plain P1 or raw P4), black pixels being the positions of the shape.
+// The south-west corner of the text or image is at position (0, 0).
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Set of positions, such as the domain of an assembly or the shape a tile set is meant to build
+type Shape map[Vec2Di]bool
+
+func NewShape(positions []Vec2Di) Shape {
+	shape := make(Shape)
+	for _, pos := range positions {
+		shape[pos] = true
+	}
+	return shape
+}
+
+// Returns the positions where there is a tile
+func (tiles TileMap) Domain() Shape {
+	shape := make(Shape)
+	for pos := range tiles {
+		shape[pos] = true
+	}
+	return shape
+}
+
+// Returns the positions in the canonical order, see TileMap.SortedPositions
+func (shape Shape) SortedPositions() []Vec2Di {
+	positions := make([]Vec2Di, 0, len(shape))
+	for pos := range shape {
+		positions = append(positions, pos)
+	}
+	sortCanonically(positions)
+	return positions
+}
+
+// Returns the lower left and upper right corners of the smallest rectangle holding the shape
+func (shape Shape) BoundingBox() (lowerLeft Vec2Di, upperRight Vec2Di) {
+	tiles := make(TileMap)
+	for pos := range shape {
+		tiles[pos] = Glues{}
+	}
+	return tiles.BoundingBox()
+}
+
+func (shape Shape) Translated(offset Vec2Di) Shape {
+	translated := make(Shape)
+	for pos := range shape {
+		translated[pos.Add(offset)] = true
+	}
+	return translated
+}
+
+func (shape Shape) IsEqualTo(otherShape Shape) bool {
+	if len(shape) != len(otherShape) {
+		return false
+	}
+	for pos := range shape {
+		if !otherShape[pos] {
+			return false
+		}
+	}
+	return true
+}
+
+// Draws the shape as ASCII art that ParseASCIIShape reads back, the south-west corner
+// of the drawing being the lower left corner of the bounding box
+func (shape Shape) ASCII() string {
+	if len(shape) == 0 {
+		return ""
+	}
+
+	lowerLeft, upperRight := shape.BoundingBox()
+	var builder strings.Builder
+
+	for y := upperRight[1]; y >= lowerLeft[1]; y -= 1 {
+		for x := lowerLeft[0]; x <= upperRight[0]; x += 1 {
+			if shape[Vec2Di{x, y}] {
+				builder.WriteByte('#')
+			} else {
+				builder.WriteByte('.')
+			}
+		}
+		builder.WriteByte('\n')
+	}
+
+	return builder.String()
+}
+
+// Parses a shape drawn as ASCII art, see the top of this file. Blank lines at the start
+// and at the end are ignored, lines can have different lengths.
+func ParseASCIIShape(text string) (Shape, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	shape := make(Shape)
+
+	for row, line := range lines {
+		y := len(lines) - 1 - row
+		for x, char := range []byte(line) {
+			switch char {
+			case '#', 'X', 'x', '*', '1':
+				shape[Vec2Di{x, y}] = true
+			case '.', ' ', '_', '0', '\t':
+			default:
+				return nil, fmt.Errorf("line %d: unexpected character %q in shape", row+1, char)
+			}
+		}
+	}
+
+	return shape, nil
+}
+
+// Reads a PBM image, plain (P1) or raw (P4), whose black pixels are the positions of the shape
+func ReadPBM(r io.Reader) (Shape, error) {
+	br := bufio.NewReader(r)
+
+	// Header fields are separated by whitespace, comments run from '#' to the end of the line
+	readField := func() (string, error) {
+		var field []byte
+		for {
+			char, err := br.ReadByte()
+			if err == io.EOF && len(field) > 0 {
+				return string(field), nil
+			}
+			if err != nil {
+				return "", errors.New("truncated PBM header")
+			}
+			switch {
+			case char == '#':
+				if _, err := br.ReadString('\n'); err != nil && err != io.EOF {
+					return "", err
+				}
+			case strings.IndexByte(" \t\r\n", char) >= 0:
+				if len(field) > 0 {
+					return string(field), nil
+				}
+			default:
+				field = append(field, char)
+			}
+		}
+	}
+
+	magic, err := readField()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if magic != "P1" && magic != "P4" {
+		return nil, fmt.Errorf("not a PBM image, magic number %q", magic)
+	}
+
+	var size [2]int
+	for i := range size {
+		field, err := readField()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fmt.Sscanf(field, "%d", &size[i]); err != nil || size[i] < 0 {
+			return nil, fmt.Errorf("invalid PBM size %q", field)
+		}
+	}
+
+	width, height := size[0], size[1]
+	shape := make(Shape)
+
+	if magic == "P4" {
+		// Rows are padded to whole bytes, the most significant bit first
+		row := make([]byte, (width+7)/8)
+		for y := height - 1; y >= 0; y -= 1 {
+			if _, err := io.ReadFull(br, row); err != nil {
+				return nil, errors.New("truncated PBM image")
+			}
+			for x := 0; x < width; x += 1 {
+				if row[x/8]&(0x80>>uint(x%8)) != 0 {
+					shape[Vec2Di{x, y}] = true
+				}
+			}
+		}
+		return shape, nil
+	}
+
+	// Plain pixels may or may not be separated by whitespace
+	for i := 0; i < width*height; {
+		char, err := br.ReadByte()
+		if err != nil {
+			return nil, errors.New("truncated PBM image")
+		}
+		switch char {
+		case '1':
+			shape[Vec2Di{i % width, height - 1 - i/width}] = true
+			i += 1
+		case '0':
+			i += 1
+		case '#':
+			if _, err := br.ReadString('\n'); err != nil {
+				return nil, errors.New("truncated PBM image")
+			}
+		case ' ', '\t', '\r', '\n':
+		default:
+			return nil, fmt.Errorf("unexpected character %q in PBM image", char)
+		}
+	}
+
+	return shape, nil
+}
+
+// Loads a shape from a PBM image if the file ends in .pbm, from ASCII art otherwise
+func LoadShape(path string) (Shape, error) {
+	if strings.ToLower(filepath.Ext(path)) == ".pbm" {
+		file, err := os.Open(path)
+
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		shape, err := ReadPBM(file)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+		return shape, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	shape, err := ParseASCIIShape(string(b))
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return shape, nil
+}
+
+// Result of comparing the domain of an assembly with a target shape translated by Offset.
+// Positions are the ones of the assembly, in the canonical order.
+type ShapeVerification struct {
+	Offset Vec2Di `json:"offset"`
+	// Positions of the target shape without tile
+	Missing []Vec2Di `json:"missing"`
+	// Positions of tiles outside of the target shape
+	Extra []Vec2Di `json:"extra"`
+}
+
+// Whether the domain is the target shape up to translation
+func (verification ShapeVerification) IsExact() bool {
+	return len(verification.Missing) == 0 && len(verification.Extra) == 0
+}
+
+// Compares the domain with the target shape translated so that the lower left corners of
+// their bounding boxes coincide
+func (domain Shape) Verify(target Shape) (verification ShapeVerification) {
+	verification.Missing = []Vec2Di{}
+	verification.Extra = []Vec2Di{}
+
+	if len(domain) > 0 && len(target) > 0 {
+		lowerLeft, _ := domain.BoundingBox()
+		targetLowerLeft, _ := target.BoundingBox()
+		verification.Offset = Vec2Di{lowerLeft[0] - targetLowerLeft[0], lowerLeft[1] - targetLowerLeft[1]}
+	}
+
+	translated := target.Translated(verification.Offset)
+
+	for _, pos := range translated.SortedPositions() {
+		if !domain[pos] {
+			verification.Missing = append(verification.Missing, pos)
+		}
+	}
+
+	for _, pos := range domain.SortedPositions() {
+		if !translated[pos] {
+			verification.Extra = append(verification.Extra, pos)
+		}
+	}
+
+	return verification
+}
+
+// Grows the assembly until it is terminal, in at most maxSteps steps if maxSteps is positive
+func growToTerminal(assembly *TileAssembly, maxSteps int) error {
+	for steps := 0; maxSteps <= 0 || steps < maxSteps; steps += 1 {
+		didGrow, err := assembly.GrowSync(true)
+
+		if err != nil || !didGrow {
+			return err
+		}
+	}
+
+	didGrow, err := assembly.GrowSync(true)
+
+	if err != nil {
+		return err
+	}
+
+	if didGrow {
+		return fmt.Errorf("no terminal assembly after %d steps", maxSteps)
+	}
+
+	return nil
+}
+
+// Grows the tile system in the directed setting until it is terminal, in at most maxSteps
+// synchronous steps if maxSteps is positive, and compares the domain of the terminal
+// assembly with the target shape. A directed system whose terminal assembly has the target
+// shape strictly self-assembles it.
+func VerifyTerminalShape(system TileSystem, target Shape, maxSteps int) (ShapeVerification, TileAssembly, error) {
+	assembly, err := system.NewAssembly()
+
+	if err != nil {
+		return ShapeVerification{}, assembly, err
+	}
+
+	if err := growToTerminal(&assembly, maxSteps); err != nil {
+		return ShapeVerification{}, assembly, err
+	}
+
+	return assembly.GetTileMap().Domain().Verify(target), assembly, nil
+}
+
+// Prints the offset then one line per position: "-" for missing positions, "+" for extra ones
+func (verification ShapeVerification) WriteText(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "offset", verification.Offset); err != nil {
+		return err
+	}
+
+	for _, pos := range verification.Missing {
+		if _, err := fmt.Fprintln(w, "-", pos); err != nil {
+			return err
+		}
+	}
+
+	for _, pos := range verification.Extra {
+		if _, err := fmt.Fprintln(w, "+", pos); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package tamtam
+
+// Compiling connected finite shapes into tile sets that strictly self-assemble them at
+// threshold 1. Tiles bind along a spanning tree of the shape rooted at the seed: each tree
+// edge carries a glue on both tiles it joins and every other side has the null glue, so that
+// each tile type can only attach where its parent expects it and growth is directed.
+//
+// With CONSTRUCTION_SPANNING_TREE the tree is a breadth-first tree and each position has its
+// own tile type. With CONSTRUCTION_ROWS each row segment is grown from the position where it
+// is entered and positions whose remaining growth (their subtree) is the same share tile
+// types, which takes far fewer tile types for shapes with repeated rows.
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+type ShapeConstruction string
+
+const (
+	// One tile type per position, bound along a breadth-first spanning tree
+	CONSTRUCTION_SPANNING_TREE ShapeConstruction = "spanning_tree"
+	// Row segments grown from their entry position, identical subtrees sharing tile types
+	CONSTRUCTION_ROWS ShapeConstruction = "rows"
+)
+
+func (construction ShapeConstruction) Validate() error {
+	switch construction {
+	case CONSTRUCTION_SPANNING_TREE, CONSTRUCTION_ROWS:
+		return nil
+	}
+	return errors.New("unknown shape construction " + string(construction))
+}
+
+// Tile system built by CompileShape
+type CompiledShape struct {
+	TileSet TileSet
+	// Position of the seed tile, the first position of the shape in the canonical order
+	SeedPos Vec2Di
+	// Seed tile, a tile of TileSet
+	Seed      SquareGlues
+	Threshold int
+}
+
+func (compiled CompiledShape) TileSystem() TileSystem {
+	return TileSystem{TileSet: compiled.TileSet, InitialTiles: TileMap{compiled.SeedPos: compiled.Seed}, Threshold: compiled.Threshold}
+}
+
+func (compiled CompiledShape) NewAssembly() TileAssembly {
+	return NewAssembly(compiled.TileSet, TileMap{compiled.SeedPos: compiled.Seed}, compiled.Threshold)
+}
+
+// Node of the spanning tree of a shape
+type shapeTreeNode struct {
+	pos Vec2Di
+	// Side of the parent, -1 for the root
+	inputSide int
+	// Index in the breadth-first order of the children on each side, -1 if none
+	children [4]int
+}
+
+// Returns the nodes of a spanning tree of the shape in breadth-first order, the root first
+func shapeSpanningTree(shape Shape, construction ShapeConstruction) ([]shapeTreeNode, error) {
+	if len(shape) == 0 {
+		return nil, errors.New("the shape is empty")
+	}
+
+	root := shape.SortedPositions()[0]
+	indices := make(map[Vec2Di]int)
+	var nodes []shapeTreeNode
+
+	// Adds the node below its parent, its parent's side being inputSide
+	addNode := func(pos Vec2Di, parent int, inputSide int) {
+		indices[pos] = len(nodes)
+		nodes = append(nodes, shapeTreeNode{pos: pos, inputSide: inputSide, children: [4]int{-1, -1, -1, -1}})
+		if parent >= 0 {
+			nodes[parent].children[squareOpposite(inputSide)] = indices[pos]
+		}
+	}
+
+	// Enters a position from its parent, with the rest of its row segment in the row construction
+	enter := func(pos Vec2Di, parent int, inputSide int) {
+		addNode(pos, parent, inputSide)
+
+		if construction != CONSTRUCTION_ROWS {
+			return
+		}
+
+		for _, side := range []int{1, 3} {
+			previous := pos
+			next := pos.Neighbors()[side]
+			for _, visited := indices[next]; shape[next] && !visited; _, visited = indices[next] {
+				addNode(next, indices[previous], squareOpposite(side))
+				previous = next
+				next = next.Neighbors()[side]
+			}
+		}
+	}
+
+	enter(root, -1, -1)
+
+	for i := 0; i < len(nodes); i += 1 {
+		pos := nodes[i].pos
+		for side, nei := range pos.Neighbors() {
+			if _, visited := indices[nei]; shape[nei] && !visited {
+				enter(nei, i, squareOpposite(side))
+			}
+		}
+	}
+
+	if len(nodes) != len(shape) {
+		return nil, fmt.Errorf("the shape is not connected, %d of its %d positions are reachable from %v", len(nodes), len(shape), root)
+	}
+
+	return nodes, nil
+}
+
+func squareOpposite(side int) int {
+	return (side + 2) % 4
+}
+
+// Returns a tile system that strictly self-assembles the shape at threshold 1, the shape
+// must be connected (through positions sharing a side). Tile types are named by integers in the
+// breadth-first order of the tree, the seed being "1", and glues are "g" followed by the
+// name of the tile type they bring in.
+func CompileShape(shape Shape, construction ShapeConstruction) (compiled CompiledShape, err error) {
+	if err := construction.Validate(); err != nil {
+		return compiled, err
+	}
+
+	nodes, err := shapeSpanningTree(shape, construction)
+
+	if err != nil {
+		return compiled, err
+	}
+
+	// Tile type of each node, given by its input side and the tile types of its children
+	// when they are shared, nodes being numbered from the leaves up
+	types := make([]int, len(nodes))
+
+	if construction == CONSTRUCTION_ROWS {
+		typeOfSubtree := make(map[[5]int]int)
+		for i := len(nodes) - 1; i >= 0; i -= 1 {
+			subtree := [5]int{nodes[i].inputSide}
+			for side, child := range nodes[i].children {
+				subtree[side+1] = -1
+				if child >= 0 {
+					subtree[side+1] = types[child]
+				}
+			}
+			if _, ok := typeOfSubtree[subtree]; !ok {
+				typeOfSubtree[subtree] = len(typeOfSubtree)
+			}
+			types[i] = typeOfSubtree[subtree]
+		}
+	} else {
+		for i := range nodes {
+			types[i] = i
+		}
+	}
+
+	// Naming tile types in the breadth-first order of their first node
+	names := make(map[int]string)
+	for _, tileType := range types {
+		if _, ok := names[tileType]; !ok {
+			names[tileType] = strconv.Itoa(len(names) + 1)
+		}
+	}
+
+	compiled.TileSet = make(TileSet)
+	compiled.Threshold = 1
+
+	for i, node := range nodes {
+		name := names[types[i]]
+		if _, ok := compiled.TileSet[name]; ok {
+			continue
+		}
+
+		var tile SquareGlues
+		if node.inputSide >= 0 {
+			tile[node.inputSide] = "g" + name
+		}
+		for side, child := range node.children {
+			if child >= 0 {
+				tile[side] = "g" + names[types[child]]
+			}
+		}
+
+		compiled.TileSet[name] = tile
+	}
+
+	compiled.SeedPos = nodes[0].pos
+	compiled.Seed = compiled.TileSet[names[types[0]]]
+
+	return compiled, nil
+}
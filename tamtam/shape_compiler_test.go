@@ -0,0 +1,101 @@
+package tamtam
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Grows the compiled shape in the directed setting and checks that it is exactly the shape
+func checkCompiledShape(t *testing.T, shape Shape, construction ShapeConstruction) CompiledShape {
+	compiled, err := CompileShape(shape, construction)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	verification, assembly, err := VerifyTerminalShape(compiled.TileSystem(), shape, 4*len(shape))
+
+	if err != nil {
+		t.Fatalf(`%s: %v`, construction, err)
+	}
+
+	// Compiled shapes are grown at their own position, not only up to translation
+	if !verification.IsExact() || verification.Offset != (Vec2Di{0, 0}) {
+		t.Fatalf(`%s: grew %s instead of %s`, construction, assembly.GetTileMap().Domain().ASCII(), shape.ASCII())
+	}
+
+	return compiled
+}
+
+func TestCompileShape(t *testing.T) {
+	for _, text := range []string{
+		"#",
+		"#....\n#....\n#####",
+		// A ring, whose hole must stay empty although tiles surround it
+		"###\n#.#\n###",
+		"..#..\n.###.\n#####\n.###.\n..#..",
+		"#.#.#\n#####\n#.#.#",
+	} {
+		shape, err := ParseASCIIShape(text)
+
+		if err != nil {
+			t.Fatalf(`%v`, err)
+		}
+
+		spanningTree := checkCompiledShape(t, shape, CONSTRUCTION_SPANNING_TREE)
+		rows := checkCompiledShape(t, shape, CONSTRUCTION_ROWS)
+
+		if len(spanningTree.TileSet) != len(shape) || len(rows.TileSet) > len(shape) {
+			t.Fatalf(`%d and %d tile types for %d positions`, len(spanningTree.TileSet), len(rows.TileSet), len(shape))
+		}
+	}
+}
+
+func TestCompileShapeRows(t *testing.T) {
+	square := make(Shape)
+	for x := 0; x < 10; x += 1 {
+		for y := 0; y < 10; y += 1 {
+			square[Vec2Di{x, y + 3}] = true
+		}
+	}
+
+	compiled := checkCompiledShape(t, square, CONSTRUCTION_ROWS)
+
+	// The first column and one row shared by all the rows
+	if len(compiled.TileSet) != 10+9 || compiled.SeedPos != (Vec2Di{0, 3}) || compiled.TileSet["1"] != compiled.Seed {
+		t.Fatalf(`Unexpected compiled square with %d tile types, seed %v at %v`, len(compiled.TileSet), compiled.Seed, compiled.SeedPos)
+	}
+}
+
+// Random connected shapes made by random walks
+func TestCompileRandomShapes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 20; i += 1 {
+		shape := make(Shape)
+		pos := Vec2Di{0, 0}
+		for step := 0; step < 200; step += 1 {
+			shape[pos] = true
+			pos = pos.Add(CardinalPoints[rng.Intn(4)])
+		}
+
+		checkCompiledShape(t, shape, CONSTRUCTION_SPANNING_TREE)
+		checkCompiledShape(t, shape, CONSTRUCTION_ROWS)
+	}
+}
+
+func TestCompileShapeErrors(t *testing.T) {
+	disconnected, _ := ParseASCIIShape("#.#")
+
+	if _, err := CompileShape(disconnected, CONSTRUCTION_SPANNING_TREE); err == nil {
+		t.Fatalf(`Disconnected shape compiled`)
+	}
+
+	if _, err := CompileShape(Shape{}, CONSTRUCTION_ROWS); err == nil {
+		t.Fatalf(`Empty shape compiled`)
+	}
+
+	if _, err := CompileShape(disconnected, "diagonals"); err == nil {
+		t.Fatalf(`Unknown construction accepted`)
+	}
+}
@@ -0,0 +1,76 @@
+package tamtam
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestASCIIShape(t *testing.T) {
+	shape, err := ParseASCIIShape(`
+#..
+##.
+###
+`)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if len(shape) != 6 || !shape[Vec2Di{0, 2}] || shape[Vec2Di{2, 2}] || !shape[Vec2Di{2, 0}] {
+		t.Fatalf(`Unexpected shape %v`, shape.SortedPositions())
+	}
+
+	if text := shape.ASCII(); text != "#..\n##.\n###\n" {
+		t.Fatalf(`Unexpected drawing %q`, text)
+	}
+
+	if _, err := ParseASCIIShape("#?"); err == nil {
+		t.Fatalf(`Unexpected character accepted`)
+	}
+}
+
+func TestPBMShape(t *testing.T) {
+	plain, err := ReadPBM(strings.NewReader("P1\n# triangle\n3 3\n1 0 0\n110\n1 1 1\n"))
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	// Same image in the raw format, rows padded to a byte
+	raw, err := ReadPBM(bytes.NewReader([]byte("P4\n3 3\n\x80\xc0\xe0")))
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	triangle, _ := ParseASCIIShape("#..\n##.\n###")
+
+	if !plain.IsEqualTo(triangle) || !raw.IsEqualTo(triangle) {
+		t.Fatalf(`Unexpected shapes %v and %v`, plain.SortedPositions(), raw.SortedPositions())
+	}
+
+	for _, image := range []string{"P2\n1 1\n1", "P1\n2 2\n1 0 1", "P4\n8 2\n\xff"} {
+		if _, err := ReadPBM(strings.NewReader(image)); err == nil {
+			t.Fatalf(`Expected an error for %q`, image)
+		}
+	}
+}
+
+func TestVerifyShape(t *testing.T) {
+	target, _ := ParseASCIIShape("#..\n##.\n###")
+	domain := target.Translated(Vec2Di{5, -2})
+
+	if verification := domain.Verify(target); !verification.IsExact() || verification.Offset != (Vec2Di{5, -2}) {
+		t.Fatalf(`Translated shape not recognized: %v`, verification)
+	}
+
+	delete(domain, Vec2Di{6, -2})
+	domain[Vec2Di{7, 0}] = true
+
+	verification := domain.Verify(target)
+
+	if verification.IsExact() || len(verification.Missing) != 1 || verification.Missing[0] != (Vec2Di{6, -2}) || len(verification.Extra) != 1 || verification.Extra[0] != (Vec2Di{7, 0}) {
+		t.Fatalf(`Unexpected verification %v`, verification)
+	}
+}
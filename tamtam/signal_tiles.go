@@ -0,0 +1,322 @@
+package tamtam
+
+// Signal tiles (active tiles): when a glue of a placed signal tile binds, rules of its
+// tile type activate or deactivate other glues of the same tile. The tile map always
+// holds the currently active glues of tiles, inactive glues being null, so that the
+// aTAM engine binds and matches signal tiles like any other tile.
+//
+// Each side fires its rules at most once, when its glue first binds. Deactivating a glue
+// only prevents future bindings, tiles never detach.
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+type SignalRule struct {
+	// Side whose glue binding fires the rule
+	Trigger int `json:"trigger"`
+	// Side whose glue is activated or deactivated
+	Target   int  `json:"target"`
+	Activate bool `json:"activate"`
+}
+
+type SignalTileType struct {
+	Glues Glues `json:"glues"`
+	// Sides whose glue is inactive when the tile is placed
+	Inactive []int        `json:"inactive,omitempty"`
+	Rules    []SignalRule `json:"rules,omitempty"`
+}
+
+type SignalTileSet map[string]SignalTileType
+
+// State of a placed signal tile
+type SignalState struct {
+	Type   string          `json:"type"`
+	Active [MAX_SIDES]bool `json:"active"`
+	// Sides whose glue has bound, and fired its rules
+	Bound [MAX_SIDES]bool `json:"bound"`
+}
+
+type PosAndSignalState struct {
+	Pos   Vec2Di      `json:"pos"`
+	State SignalState `json:"state"`
+}
+
+func (signalTileSet SignalTileSet) IsEqualTo(otherSignalTileSet SignalTileSet) bool {
+	return len(signalTileSet) == len(otherSignalTileSet) && (len(signalTileSet) == 0 || reflect.DeepEqual(signalTileSet, otherSignalTileSet))
+}
+
+func (tileType SignalTileType) initialState(name string) (state SignalState) {
+	state.Type = name
+	for side := range state.Active {
+		state.Active[side] = true
+	}
+	for _, side := range tileType.Inactive {
+		state.Active[side] = false
+	}
+	return state
+}
+
+// Returns the glues of the tile type which are active in the state, the other ones being null
+func (tileType SignalTileType) activeGlues(state SignalState) (glues Glues) {
+	for side, glue := range tileType.Glues {
+		if state.Active[side] {
+			glues[side] = glue
+		}
+	}
+	return glues
+}
+
+func (tileType SignalTileType) validate(name string, lattice Lattice) error {
+	if !lattice.fits(tileType.Glues) {
+		return fmt.Errorf("signal tile %q has glues on sides that %s tiles do not have", name, lattice)
+	}
+
+	checkSide := func(side int) error {
+		if side < 0 || side >= lattice.Sides() {
+			return fmt.Errorf("signal tile %q refers to side %d, %s tiles have %d sides", name, side, lattice, lattice.Sides())
+		}
+		return nil
+	}
+
+	for _, side := range tileType.Inactive {
+		if err := checkSide(side); err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range tileType.Rules {
+		if err := checkSide(rule.Trigger); err != nil {
+			return err
+		}
+		if err := checkSide(rule.Target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Adds signal tile types to the ones the assembly grows with, meant to be called before growth.
+// Tiles are placed with their initial glues, which must differ from the glues of the other tile
+// types so that placed tiles are recognized. Signal tiles cannot be rotated.
+func (assembly *TileAssembly) SetSignalTileSet(signalTileSet SignalTileSet) error {
+	if assembly.orientations != ORIENTATIONS_FIXED && len(signalTileSet) > 0 {
+		return errors.New("signal tiles cannot be rotated")
+	}
+
+	initialGlues := make(map[Glues]string)
+	for _, name := range assembly.TileSet.SortedNames() {
+		initialGlues[assembly.TileSet[name]] = name
+	}
+
+	placeable := make(TileSet)
+	for name, tile := range assembly.TileSet {
+		placeable[name] = tile
+	}
+
+	names := make([]string, 0, len(signalTileSet))
+	for name := range signalTileSet {
+		names = append(names, name)
+	}
+	sortNames(names)
+
+	for _, name := range names {
+		tileType := signalTileSet[name]
+
+		if err := tileType.validate(name, assembly.lattice); err != nil {
+			return err
+		}
+
+		glues := tileType.activeGlues(tileType.initialState(name))
+
+		if otherName, ok := initialGlues[glues]; ok {
+			return fmt.Errorf("signal tile %q is placed with the same glues as tile %q", name, otherName)
+		}
+
+		if _, ok := placeable[name]; ok {
+			return fmt.Errorf("signal tile %q has the name of a tile type", name)
+		}
+
+		initialGlues[glues] = name
+		placeable[name] = glues
+	}
+
+	assembly.signalTileSet = signalTileSet
+	assembly.signalTypes = make(map[Glues]string)
+	for _, name := range names {
+		tileType := signalTileSet[name]
+		assembly.signalTypes[tileType.activeGlues(tileType.initialState(name))] = name
+	}
+	assembly.placeableTileSet = placeable
+	assembly.signalStates = make(map[Vec2Di]SignalState)
+
+	return nil
+}
+
+func (assembly TileAssembly) GetSignalTileSet() SignalTileSet {
+	return assembly.signalTileSet
+}
+
+// Returns the states of the placed signal tiles in the canonical order of their positions
+func (assembly TileAssembly) GetSignalStates() []PosAndSignalState {
+	positions := make([]Vec2Di, 0, len(assembly.signalStates))
+	for pos := range assembly.signalStates {
+		positions = append(positions, pos)
+	}
+	sortCanonically(positions)
+
+	states := make([]PosAndSignalState, len(positions))
+	for i, pos := range positions {
+		states[i] = PosAndSignalState{Pos: pos, State: assembly.signalStates[pos]}
+	}
+	return states
+}
+
+// Restores the states of placed signal tiles, the tiles must already be in the assembly
+// with the active glues of their state
+func (assembly *TileAssembly) setSignalStates(states []PosAndSignalState) error {
+	for _, posAndState := range states {
+		pos := assembly.geometry.Normalize(posAndState.Pos)
+		tileType, ok := assembly.signalTileSet[posAndState.State.Type]
+
+		if !ok {
+			return fmt.Errorf("unknown signal tile %q at %v", posAndState.State.Type, posAndState.Pos)
+		}
+
+		if tile, ok := assembly.tileMap[pos]; !ok || tile != tileType.activeGlues(posAndState.State) {
+			return fmt.Errorf("the tile at %v does not have the glues of its signal state", posAndState.Pos)
+		}
+
+		assembly.signalStates[pos] = posAndState.State
+	}
+
+	return nil
+}
+
+// Places a tile found by growth, firing the signals of the signal tiles it binds with,
+// itself included, then looking for the tiles its repulsions made unstable
+func (assembly *TileAssembly) placeTile(pos Vec2Di, tile SquareGlues) {
+	pos = assembly.geometry.Normalize(pos)
+
+	repulsion := assembly.glueStrengths.hasRepulsion()
+	var wasUnstable map[Vec2Di]bool
+	if repulsion {
+		wasUnstable = assembly.unstableAround(pos)
+	}
+
+	assembly.AddTile(pos, tile)
+
+	if len(assembly.signalTileSet) > 0 {
+		if name, ok := assembly.signalTypes[tile]; ok {
+			assembly.signalStates[pos] = assembly.signalTileSet[name].initialState(name)
+		}
+
+		assembly.fireSignals(assembly.bindingsOf(pos, nil))
+	}
+
+	if repulsion {
+		if assembly.detachment == DETACHMENT_UNSTABLE {
+			if assembly.grownTiles == nil {
+				assembly.grownTiles = make(Shape)
+			}
+			assembly.grownTiles[pos] = true
+		}
+
+		assembly.checkStability(pos, wasUnstable)
+
+		// A tile matched in the same synchronous step as a detachment may have nothing left to bind to
+		if _, total := assembly.bindingStrengths(pos); assembly.grownTiles[pos] && total < assembly.threshold {
+			assembly.detach(pos)
+		}
+	}
+}
+
+type posAndSide struct {
+	pos  Vec2Di
+	side int
+}
+
+// Returns the sides of the tile and of its neighbors that bind together, both sides of each bond.
+// Only the given sides are looked at, all of them if nil.
+func (assembly TileAssembly) bindingsOf(pos Vec2Di, sides []int) (bindings []posAndSide) {
+	if sides == nil {
+		for side := 0; side < assembly.lattice.Sides(); side += 1 {
+			sides = append(sides, side)
+		}
+	}
+
+	tile := assembly.tileMap[pos]
+	neighbors := assembly.neighbors(pos)
+
+	for _, side := range sides {
+		opposite := assembly.lattice.Opposite(side)
+		neighbor, ok := assembly.tileMap[neighbors[side]]
+
+		if ok && tile[side] != NULL_GLUE && tile[side] == neighbor[opposite] && assembly.glueStrengths.Strength(tile[side]) > 0 {
+			bindings = append(bindings, posAndSide{pos: pos, side: side}, posAndSide{pos: neighbors[side], side: opposite})
+		}
+	}
+
+	return bindings
+}
+
+// Fires the rules of the signal tiles whose glues bind, until no new binding happens
+func (assembly *TileAssembly) fireSignals(bindings []posAndSide) {
+	for len(bindings) > 0 {
+		binding := bindings[0]
+		bindings = bindings[1:]
+
+		state, ok := assembly.signalStates[binding.pos]
+
+		if !ok || state.Bound[binding.side] {
+			continue
+		}
+
+		state.Bound[binding.side] = true
+		tileType := assembly.signalTileSet[state.Type]
+
+		var changedSides []int
+		for _, rule := range tileType.Rules {
+			if rule.Trigger == binding.side && state.Active[rule.Target] != rule.Activate {
+				state.Active[rule.Target] = rule.Activate
+				changedSides = append(changedSides, rule.Target)
+			}
+		}
+
+		assembly.signalStates[binding.pos] = state
+
+		if len(changedSides) == 0 {
+			continue
+		}
+
+		assembly.setGlues(binding.pos, tileType.activeGlues(state))
+
+		// Activated glues may bind with the neighbors already there
+		bindings = append(bindings, assembly.bindingsOf(binding.pos, changedSides)...)
+	}
+}
+
+// Changes the glues of a placed tile and updates the frontier around it. Observers
+// see the tile being removed and added back with its new glues.
+func (assembly *TileAssembly) setGlues(pos Vec2Di, tile SquareGlues) {
+	oldTile := assembly.tileMap[pos]
+	assembly.tileMap[pos] = tile
+
+	neighbors := assembly.neighbors(pos)
+	for _, nei := range neighbors[:assembly.lattice.Sides()] {
+		if _, ok := assembly.tileMap[nei]; ok {
+			continue
+		}
+		if assembly.geometry.Contains(nei) && assembly.isPosAboveThreshold(nei) {
+			assembly.emptyPositionsAboveThreshold.Add(nei)
+		} else {
+			assembly.emptyPositionsAboveThreshold.Remove(nei)
+		}
+	}
+
+	assembly.notifyTileRemoved(pos, oldTile)
+	assembly.notifyTileAdded(pos, tile)
+}
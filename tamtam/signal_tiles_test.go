@@ -0,0 +1,109 @@
+package tamtam
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// The signal tile binds on its west side, which activates its east glue and deactivates its north glue
+func newSignalAssembly(t *testing.T) TileAssembly {
+	tileSet := TileSet{
+		"end": SquareGlues{NULL_GLUE, NULL_GLUE, NULL_GLUE, "b"},
+		"top": SquareGlues{NULL_GLUE, NULL_GLUE, "n", NULL_GLUE},
+	}
+	seed := TileMap{{0, 0}: SquareGlues{NULL_GLUE, "a", NULL_GLUE, NULL_GLUE}}
+
+	assembly := NewAssembly(tileSet, seed, 1)
+
+	err := assembly.SetSignalTileSet(SignalTileSet{
+		"switch": SignalTileType{
+			Glues:    SquareGlues{"n", "b", NULL_GLUE, "a"},
+			Inactive: []int{1},
+			Rules:    []SignalRule{{Trigger: 3, Target: 1, Activate: true}, {Trigger: 3, Target: 0, Activate: false}},
+		},
+	})
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	return assembly
+}
+
+func TestSignalTiles(t *testing.T) {
+	assembly := newSignalAssembly(t)
+	growUntilTerminal(t, &assembly)
+
+	tileMap := assembly.GetTileMap()
+
+	if assembly.Size() != 3 || tileMap[Vec2Di{2, 0}] != assembly.TileSet["end"] {
+		t.Fatalf(`Unexpected tiles %v`, tileMap)
+	}
+
+	if tile := tileMap[Vec2Di{1, 0}]; tile != (SquareGlues{NULL_GLUE, "b", NULL_GLUE, "a"}) {
+		t.Fatalf(`The glues of the signal tile are %v`, tile)
+	}
+
+	states := assembly.GetSignalStates()
+
+	if len(states) != 1 || states[0].State.Type != "switch" || !states[0].State.Bound[3] || !states[0].State.Bound[1] || states[0].State.Bound[0] {
+		t.Fatalf(`Unexpected signal states %+v`, states)
+	}
+
+	if counts := assembly.Statistics().TileCounts; counts["switch"] != 1 {
+		t.Fatalf(`Unexpected tile counts %v`, counts)
+	}
+
+	b, err := json.Marshal(assembly)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	var decoded TileAssembly
+
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !decoded.IsEqualTo(assembly) {
+		t.Fatalf(`Decoded assembly differs from the original one`)
+	}
+}
+
+func TestSignalTilesCheckpoint(t *testing.T) {
+	assembly := newSignalAssembly(t)
+	assembly.GrowSync(true)
+
+	var buffer bytes.Buffer
+
+	if err := assembly.WriteCheckpoint(&buffer); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	resumed, err := ReadCheckpoint(&buffer)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	growUntilTerminal(t, &assembly)
+	growUntilTerminal(t, &resumed)
+
+	if !resumed.IsEqualTo(assembly) {
+		t.Fatalf(`Resumed assembly differs from the original one`)
+	}
+}
+
+func TestSignalTileSetErrors(t *testing.T) {
+	assembly := NewAssembly(TileSet{"a": SquareGlues{"x", NULL_GLUE, NULL_GLUE, NULL_GLUE}}, TileMap{}, 1)
+
+	if err := assembly.SetSignalTileSet(SignalTileSet{"s": {Glues: SquareGlues{"x", "y", NULL_GLUE, NULL_GLUE}, Inactive: []int{1}}}); err == nil {
+		t.Fatalf(`Signal tile placed with the glues of a tile type accepted`)
+	}
+
+	if err := assembly.SetSignalTileSet(SignalTileSet{"s": {Glues: SquareGlues{"y"}, Rules: []SignalRule{{Trigger: 0, Target: 4}}}}); err == nil {
+		t.Fatalf(`Rule on a fifth side of a square tile accepted`)
+	}
+}
@@ -0,0 +1,183 @@
+package tamtam
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Smallest square NewSquareTileSystem can build
+const MIN_SQUARE_SIZE = 4
+
+// Glue of the filler tiles, on the outer sides of the counters
+const SQUARE_FILLER_GLUE = "f"
+
+// Start value, number of bits and kind of seed of a counter that stops after length rows (seed
+// included). Counters whose seed is an increment row have an odd length, other ones an even length.
+func squareCounterParameters(length int) (start int, bits int, seedIsIncrement bool) {
+	seedIsIncrement = length%2 == 1
+	increments := length / 2
+
+	bits = 2
+	for 1<<uint(bits) < increments {
+		bits += 1
+	}
+
+	return (1 << uint(bits)) - increments, bits, seedIsIncrement
+}
+
+// Tile types of the vertical counter, see the top of this file. The least significant bit is
+// on the west side, the most significant bit has the filler glue on its east side.
+func verticalCounterTiles() TileSet {
+	tiles := make(TileSet)
+
+	for b := 0; b < 2; b += 1 {
+		bit := strconv.Itoa(b)
+
+		// Increment rows, the least significant bit always receives a carry
+		tiles["inc_lsb_"+bit] = SquareGlues{"nl" + strconv.Itoa(1-b), "k" + bit, "s" + bit, NULL_GLUE}
+
+		for c := 0; c < 2; c += 1 {
+			carry := strconv.Itoa(c)
+			sum := strconv.Itoa(b ^ c)
+
+			tiles["inc_"+bit+"_"+carry] = SquareGlues{"n" + sum, "k" + strconv.Itoa(b&c), "c" + bit, "k" + carry}
+
+			north := "t" + sum
+			if b&c == 1 {
+				// Overflow, the counter stops
+				north = NULL_GLUE
+			}
+			tiles["inc_msb_"+bit+"_"+carry] = SquareGlues{north, SQUARE_FILLER_GLUE, "cm" + bit, "k" + carry}
+		}
+
+		// Copy rows
+		tiles["copy_msb_"+bit] = SquareGlues{"cm" + bit, SQUARE_FILLER_GLUE, "t" + bit, "w"}
+		tiles["copy_"+bit] = SquareGlues{"c" + bit, "w", "n" + bit, "w"}
+		tiles["copy_lsb_"+bit] = SquareGlues{"s" + bit, "w", "nl" + bit, NULL_GLUE}
+	}
+
+	return tiles
+}
+
+// Glues of strength 2 of the vertical counter, the ones starting rows
+func verticalCounterStrengths() GlueStrengths {
+	return GlueStrengths{"s0": 2, "s1": 2, "t0": 2, "t1": 2}
+}
+
+// Seed row of the vertical counter holding the start value, from west to east
+func counterSeedRow(start int, bits int, seedIsIncrement bool) []SquareGlues {
+	row := make([]SquareGlues, bits)
+
+	for i := range row {
+		bit := strconv.Itoa((start >> uint(i)) & 1)
+
+		switch {
+		case i == 0 && seedIsIncrement:
+			row[i][0] = "nl" + bit
+		case i == 0:
+			row[i][0] = "s" + bit
+		case i == bits-1 && seedIsIncrement:
+			row[i][0] = "t" + bit
+		case i == bits-1:
+			row[i][0] = "cm" + bit
+		case seedIsIncrement:
+			row[i][0] = "n" + bit
+		default:
+			row[i][0] = "c" + bit
+		}
+	}
+
+	row[bits-1][1] = SQUARE_FILLER_GLUE
+
+	return row
+}
+
+// Reflects a tile of the vertical counter across the diagonal (north and east are swapped, as
+// are south and west) into a tile of the horizontal counter, whose glues are prefixed with "h_"
+func horizontalCounterTile(tile SquareGlues) (reflected SquareGlues) {
+	for side, otherSide := range []int{1, 0, 3, 2} {
+		if glue := tile[otherSide]; glue != NULL_GLUE && glue != SQUARE_FILLER_GLUE {
+			reflected[side] = "h_" + glue
+		} else {
+			reflected[side] = glue
+		}
+	}
+	return reflected
+}
+
+// Returns a tile system whose terminal assembly at threshold 2 is the n×n square with its lower
+// left corner at (0, 0), n being at least MIN_SQUARE_SIZE, with O(log n) tile types: the ones of
+// both counters, the column starting the horizontal counter and the filler. The seed row holds
+// the start value of the vertical counter.
+//
+// A zig-zag binary counter grows north from the seed row, which holds its start value with
+// the least significant bit to the west. Increment rows are built west to east, the carry
+// going east, and copy rows east to west, each row starting with a strength 2 glue left
+// by the row below. The increment that overflows places no glue for a copy row, so that
+// the counter stops after exactly n rows.
+//
+// The same counter reflected across the diagonal grows east along the bottom of the square
+// from a column of tile types holding its own start value, and stops after the remaining
+// width of the square. Fillers, binding with their west and south neighbors, fill the
+// rectangle between both counters.
+//
+//	^ vertical counter (n rows)
+//	|
+//	|ffffffffff
+//	|ffffffffff
+//	|ffffffffff
+//	|ccccccccccc   horizontal counter
+//	|ccccccccccc   (n minus the width of the vertical counter columns)
+//	seed row
+func NewSquareTileSystem(n int) (system TileSystem, err error) {
+	if n < MIN_SQUARE_SIZE {
+		return system, fmt.Errorf("squares must be at least %d tiles wide, not %d", MIN_SQUARE_SIZE, n)
+	}
+
+	verticalStart, verticalBits, verticalSeedIsIncrement := squareCounterParameters(n)
+	horizontalStart, horizontalBits, horizontalSeedIsIncrement := squareCounterParameters(n - verticalBits)
+
+	system.Threshold = 2
+	system.TileSet = make(TileSet)
+	system.GlueStrengths = verticalCounterStrengths()
+	system.InitialTiles = make(TileMap)
+
+	for name, tile := range verticalCounterTiles() {
+		system.TileSet[name] = tile
+		system.TileSet["h_"+name] = horizontalCounterTile(tile)
+	}
+
+	for glue, strength := range verticalCounterStrengths() {
+		system.GlueStrengths["h_"+glue] = strength
+	}
+
+	system.TileSet["filler"] = SquareGlues{SQUARE_FILLER_GLUE, SQUARE_FILLER_GLUE, SQUARE_FILLER_GLUE, SQUARE_FILLER_GLUE}
+
+	// The seed row starts the column holding the start value of the horizontal counter
+	for i, tile := range counterSeedRow(verticalStart, verticalBits, verticalSeedIsIncrement) {
+		if i == verticalBits-1 {
+			tile[1] = "start_column"
+		}
+		system.InitialTiles[Vec2Di{i, 0}] = tile
+	}
+	system.GlueStrengths["start_column"] = 2
+
+	for i, tile := range counterSeedRow(horizontalStart, horizontalBits, horizontalSeedIsIncrement) {
+		tile = horizontalCounterTile(tile)
+
+		if i == 0 {
+			tile[3] = "start_column"
+		} else {
+			tile[2] = "column_" + strconv.Itoa(i)
+		}
+
+		if i < horizontalBits-1 {
+			tile[0] = "column_" + strconv.Itoa(i+1)
+			system.GlueStrengths[tile[0]] = 2
+		}
+
+		system.TileSet["column_"+strconv.Itoa(i)] = tile
+	}
+
+	return system, nil
+}
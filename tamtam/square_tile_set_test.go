@@ -0,0 +1,77 @@
+package tamtam
+
+import "testing"
+
+func squareShape(n int) Shape {
+	square := make(Shape)
+	for x := 0; x < n; x += 1 {
+		for y := 0; y < n; y += 1 {
+			square[Vec2Di{x, y}] = true
+		}
+	}
+	return square
+}
+
+// Every square is grown exactly, in place, by a directed system
+func TestNewSquareTileSystem(t *testing.T) {
+	sizes := []int{}
+	for n := MIN_SQUARE_SIZE; n <= 40; n += 1 {
+		sizes = append(sizes, n)
+	}
+	sizes = append(sizes, 63, 64, 65, 100, 129)
+
+	for _, n := range sizes {
+		system, err := NewSquareTileSystem(n)
+
+		if err != nil {
+			t.Fatalf(`%d: %v`, n, err)
+		}
+
+		if system.Threshold != 2 {
+			t.Fatalf(`%d: threshold %d`, n, system.Threshold)
+		}
+
+		verification, assembly, err := VerifyTerminalShape(system, squareShape(n), 4*n*n)
+
+		if err != nil {
+			t.Fatalf(`%d: %v`, n, err)
+		}
+
+		if !verification.IsExact() || verification.Offset != (Vec2Di{0, 0}) {
+			t.Fatalf(`%d: grew %s`, n, assembly.GetTileMap().Domain().ASCII())
+		}
+	}
+}
+
+// Both counters take 16 tile types, the filler one and the column starting the horizontal
+// counter one per bit
+func TestNewSquareTileSystemComplexity(t *testing.T) {
+	for _, test := range []struct {
+		n         int
+		tileTypes int
+	}{
+		{4, 35},
+		{16, 36},
+		{100, 39},
+		{1000, 42},
+		{1000000, 52},
+	} {
+		system, err := NewSquareTileSystem(test.n)
+
+		if err != nil {
+			t.Fatalf(`%d: %v`, test.n, err)
+		}
+
+		if len(system.TileSet) != test.tileTypes {
+			t.Fatalf(`%d: %d tile types instead of %d`, test.n, len(system.TileSet), test.tileTypes)
+		}
+	}
+}
+
+func TestNewSquareTileSystemErrors(t *testing.T) {
+	for _, n := range []int{-1, 0, 1, MIN_SQUARE_SIZE - 1} {
+		if _, err := NewSquareTileSystem(n); err == nil {
+			t.Fatalf(`no error for %d`, n)
+		}
+	}
+}
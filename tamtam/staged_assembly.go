@@ -0,0 +1,339 @@
+package tamtam
+
+// Staged self-assembly (Demaine et al., Staged self-assembly: nanomanufacture of arbitrary
+// shapes with O(1) glues): bins are mixed in stages, the terminal products of earlier bins being
+// poured into later bins with their own tile types. Each bin follows the two-handed model: the
+// poured products, the seed of the bin and its tile types, all in unlimited supply, bind two
+// at a time, whole assemblies included, and the products of the bin are the assemblies which
+// bind to no other.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+type StagedBin struct {
+	Name          string        `json:"name"`
+	TileSet       TileSet       `json:"tile_set"`
+	GlueStrengths GlueStrengths `json:"glue_strengths,omitempty"`
+	Threshold     int           `json:"threshold"`
+	// Bins whose products are poured into this bin
+	Inputs []string `json:"inputs,omitempty"`
+	// Assembly mixed in the bin besides the products of its inputs
+	Seed TileMap `json:"seed,omitempty"`
+}
+
+// Bins forming a directed acyclic graph through their inputs
+type StagedSystem struct {
+	Bins []StagedBin `json:"bins"`
+	// A bin stops with an error once it produced more than that many assemblies, which happens
+	// when its assemblies can grow forever, no limit if 0
+	MaxAssemblies int `json:"max_assemblies,omitempty"`
+}
+
+type StagedResult struct {
+	// Terminal products of each bin
+	Products map[string][]TileAssembly `json:"products"`
+	// Stage of each bin: 1 for bins without inputs, one more than their latest input otherwise
+	Stages map[string]int `json:"stages"`
+	// Number of stages, of bins, largest number of bins in a stage and number of distinct tile types of the bins
+	StageCount     int `json:"stage_count"`
+	BinCount       int `json:"bin_count"`
+	MaxBinsInStage int `json:"max_bins_in_stage"`
+	TileComplexity int `json:"tile_complexity"`
+}
+
+// Returns the bins in an order where inputs come before the bins they are poured into,
+// or an error if the bins are not a DAG
+func (system StagedSystem) sortedBins() ([]StagedBin, error) {
+	binIndices := make(map[string]int)
+
+	for i, bin := range system.Bins {
+		if bin.Name == "" {
+			return nil, fmt.Errorf("bin %d has no name", i+1)
+		}
+		if _, ok := binIndices[bin.Name]; ok {
+			return nil, fmt.Errorf("two bins are named %q", bin.Name)
+		}
+		binIndices[bin.Name] = i
+	}
+
+	for _, bin := range system.Bins {
+		if len(bin.Inputs) == 0 && len(bin.Seed) == 0 && len(bin.TileSet) == 0 {
+			return nil, fmt.Errorf("bin %q has no tiles, inputs nor seed", bin.Name)
+		}
+		for _, input := range bin.Inputs {
+			if _, ok := binIndices[input]; !ok {
+				return nil, fmt.Errorf("bin %q has unknown input %q", bin.Name, input)
+			}
+		}
+	}
+
+	// Depth first search, bins being sorted after their inputs
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make([]int, len(system.Bins))
+	var sorted []StagedBin
+
+	var visit func(index int) error
+	visit = func(index int) error {
+		switch marks[index] {
+		case visiting:
+			return fmt.Errorf("bin %q is one of its own inputs", system.Bins[index].Name)
+		case visited:
+			return nil
+		}
+
+		marks[index] = visiting
+		for _, input := range system.Bins[index].Inputs {
+			if err := visit(binIndices[input]); err != nil {
+				return err
+			}
+		}
+		marks[index] = visited
+
+		sorted = append(sorted, system.Bins[index])
+		return nil
+	}
+
+	for i := range system.Bins {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+// Checks that bins have unique names, known inputs, no cycles and something to grow
+func (system StagedSystem) Validate() error {
+	_, err := system.sortedBins()
+	return err
+}
+
+// Number of distinct tile types (by glues) among the tile sets of the bins
+func (system StagedSystem) TileComplexity() int {
+	tileTypes := make(map[SquareGlues]bool)
+	for _, bin := range system.Bins {
+		for _, tile := range bin.TileSet {
+			tileTypes[tile] = true
+		}
+	}
+	return len(tileTypes)
+}
+
+// Returns the tiles translated so that the lower left corner of their bounding box is the origin,
+// as a key telling assemblies apart up to translation
+func translationKey(tiles TileMap) string {
+	lowerLeft, _ := tiles.BoundingBox()
+
+	var key strings.Builder
+	for _, pos := range tiles.SortedPositions() {
+		fmt.Fprintf(&key, "%d,%d%q;", pos[0]-lowerLeft[0], pos[1]-lowerLeft[1], tiles[pos])
+	}
+	return key.String()
+}
+
+// Returns the strength with which the tiles, once translated, bind to the assembly, and false if
+// they overlap it
+func (bin StagedBin) bindingStrength(assembly TileMap, tiles TileMap, translation Vec2Di) (strength int, ok bool) {
+	for pos, tile := range tiles {
+		pos = pos.Add(translation)
+
+		if _, overlap := assembly[pos]; overlap {
+			return 0, false
+		}
+
+		for side, nei := range pos.Neighbors() {
+			if neiTile, bound := assembly[nei]; bound && tile[side] != NULL_GLUE && tile[side] == neiTile[(side+2)%4] {
+				strength += bin.GlueStrengths.Strength(tile[side])
+			}
+		}
+	}
+	return strength, true
+}
+
+// Returns the translations of the tiles with which they bind to the assembly at the threshold
+// of the bin, in a deterministic order. Only the translations making a positive glue of the
+// tiles match a glue of the assembly are tried, as any binding one does.
+func (bin StagedBin) bindingTranslations(assembly TileMap, tiles TileMap) (translations []Vec2Di) {
+	tried := make(map[Vec2Di]bool)
+
+	for _, pos := range assembly.SortedPositions() {
+		for side, nei := range pos.Neighbors() {
+			glue := assembly[pos][side]
+
+			if _, ok := assembly[nei]; ok || glue == NULL_GLUE || bin.GlueStrengths.Strength(glue) <= 0 {
+				continue
+			}
+
+			for _, tilePos := range tiles.SortedPositions() {
+				translation := Vec2Di{nei[0] - tilePos[0], nei[1] - tilePos[1]}
+
+				if tiles[tilePos][(side+2)%4] != glue || tried[translation] {
+					continue
+				}
+				tried[translation] = true
+
+				if strength, ok := bin.bindingStrength(assembly, tiles, translation); ok && strength >= bin.Threshold {
+					translations = append(translations, translation)
+				}
+			}
+		}
+	}
+
+	return translations
+}
+
+// Mixes the assemblies with the tiles of the bin, each of them in unlimited supply, and returns
+// the terminal assemblies in the order they were produced. Two assemblies, a single tile being
+// one, bind when one of them can be translated next to the other without overlapping it so that
+// their matching glues reach the threshold, which produces a new assembly. Fails once more
+// than maxAssemblies assemblies were produced if maxAssemblies is positive.
+func (bin StagedBin) mix(inputs []TileMap, maxAssemblies int) (products []TileMap, err error) {
+	var assemblies []TileMap
+	keys := make(map[string]bool)
+
+	add := func(tiles TileMap) {
+		if key := translationKey(tiles); !keys[key] {
+			keys[key] = true
+			assemblies = append(assemblies, tiles)
+		}
+	}
+
+	for _, tiles := range inputs {
+		add(tiles)
+	}
+
+	for _, name := range bin.TileSet.SortedNames() {
+		add(TileMap{Vec2Di{0, 0}: bin.TileSet[name]})
+	}
+
+	binds := make(map[int]bool)
+
+	// Each pair of assemblies is mixed once, when the latest of the two is reached
+	for i := 0; i < len(assemblies); i += 1 {
+		if maxAssemblies > 0 && len(assemblies) > maxAssemblies {
+			return nil, fmt.Errorf("more than %d assemblies", maxAssemblies)
+		}
+
+		for j := 0; j <= i; j += 1 {
+			for _, translation := range bin.bindingTranslations(assemblies[i], assemblies[j]) {
+				binds[i], binds[j] = true, true
+
+				combined := make(TileMap)
+				for pos, tile := range assemblies[i] {
+					combined[pos] = tile
+				}
+				for pos, tile := range assemblies[j] {
+					combined[pos.Add(translation)] = tile
+				}
+
+				add(combined)
+			}
+		}
+	}
+
+	for i, tiles := range assemblies {
+		if !binds[i] {
+			products = append(products, tiles)
+		}
+	}
+
+	return products, nil
+}
+
+// Runs the bins in the order of their stages and returns their terminal products.
+// The products of the inputs of a bin, its seed and its tile types are mixed following
+// the two-handed model: any two of them bind when they can be placed side by side at
+// the threshold of the bin, see StagedBin.mix.
+func (system StagedSystem) Run() (result StagedResult, err error) {
+	bins, err := system.sortedBins()
+
+	if err != nil {
+		return result, err
+	}
+
+	if len(bins) == 0 {
+		return result, errors.New("the staged system has no bin")
+	}
+
+	result.Products = make(map[string][]TileAssembly)
+	result.Stages = make(map[string]int)
+	binsInStage := make(map[int]int)
+
+	for _, bin := range bins {
+		var inputs []TileMap
+
+		stage := 1
+		for _, input := range bin.Inputs {
+			if result.Stages[input]+1 > stage {
+				stage = result.Stages[input] + 1
+			}
+			for _, product := range result.Products[input] {
+				inputs = append(inputs, product.GetTileMap())
+			}
+		}
+
+		if len(bin.Seed) > 0 {
+			inputs = append(inputs, bin.Seed)
+		}
+
+		terminal, err := bin.mix(inputs, system.MaxAssemblies)
+
+		if err != nil {
+			return result, fmt.Errorf("bin %q: %v", bin.Name, err)
+		}
+
+		products := []TileAssembly{}
+		for _, tiles := range terminal {
+			products = append(products, NewAssemblyWithGlueStrengths(bin.TileSet, bin.GlueStrengths, tiles, bin.Threshold))
+		}
+
+		result.Products[bin.Name] = products
+		result.Stages[bin.Name] = stage
+		binsInStage[stage] += 1
+
+		if stage > result.StageCount {
+			result.StageCount = stage
+		}
+	}
+
+	for _, count := range binsInStage {
+		if count > result.MaxBinsInStage {
+			result.MaxBinsInStage = count
+		}
+	}
+
+	result.BinCount = len(bins)
+	result.TileComplexity = system.TileComplexity()
+
+	return result, nil
+}
+
+// Prints the complexity measures, then the stage and product sizes of each bin
+func (result StagedResult) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Stages\t%d\n", result.StageCount)
+	fmt.Fprintf(tw, "Bins\t%d\n", result.BinCount)
+	fmt.Fprintf(tw, "Most bins in a stage\t%d\n", result.MaxBinsInStage)
+	fmt.Fprintf(tw, "Tile complexity\t%d\n", result.TileComplexity)
+
+	fmt.Fprintln(tw, "\nBin\tStage\tProduct sizes")
+	for _, name := range sortedKeys(result.Stages) {
+		sizes := make([]int, len(result.Products[name]))
+		for i, product := range result.Products[name] {
+			sizes[i] = product.Size()
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%v\n", name, result.Stages[name], sizes)
+	}
+
+	return tw.Flush()
+}
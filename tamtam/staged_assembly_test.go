@@ -0,0 +1,181 @@
+package tamtam
+
+import (
+	"testing"
+)
+
+// Two bins grow a horizontal and a vertical bar in the first stage, a third bin mixes them with
+// tiles extending the bars in the second stage
+func newStagedSystem() StagedSystem {
+	return StagedSystem{Bins: []StagedBin{
+		{
+			Name:      "horizontal",
+			TileSet:   TileSet{"h": SquareGlues{NULL_GLUE, "h", NULL_GLUE, "h"}},
+			Threshold: 1,
+			Seed:      TileMap{Vec2Di{0, 0}: SquareGlues{NULL_GLUE, "h", NULL_GLUE, NULL_GLUE}},
+		},
+		{
+			Name:      "vertical",
+			TileSet:   TileSet{"v": SquareGlues{NULL_GLUE, NULL_GLUE, "v", NULL_GLUE}},
+			Threshold: 1,
+			Seed:      TileMap{Vec2Di{0, 0}: SquareGlues{"v", NULL_GLUE, NULL_GLUE, NULL_GLUE}},
+		},
+		{
+			Name:      "extended",
+			TileSet:   TileSet{"h": SquareGlues{NULL_GLUE, NULL_GLUE, NULL_GLUE, "e"}, "v": SquareGlues{NULL_GLUE, NULL_GLUE, "w", NULL_GLUE}},
+			Threshold: 1,
+			Inputs:    []string{"horizontal", "vertical"},
+		},
+	}}
+}
+
+func TestStagedAssembly(t *testing.T) {
+	system := newStagedSystem()
+	// The horizontal bar stops after its end tile
+	system.Bins[0].TileSet = TileSet{"h_end": SquareGlues{NULL_GLUE, "e", NULL_GLUE, "h"}}
+
+	result, err := system.Run()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if result.StageCount != 2 || result.BinCount != 3 || result.MaxBinsInStage != 2 || result.TileComplexity != 4 {
+		t.Fatalf(`Unexpected complexity %d stages, %d bins, %d bins in a stage, %d tile types`, result.StageCount, result.BinCount, result.MaxBinsInStage, result.TileComplexity)
+	}
+
+	if result.Stages["horizontal"] != 1 || result.Stages["extended"] != 2 {
+		t.Fatalf(`Unexpected stages %v`, result.Stages)
+	}
+
+	if products := result.Products["horizontal"]; len(products) != 1 || products[0].Size() != 2 {
+		t.Fatalf(`Unexpected products of the horizontal bin`)
+	}
+
+	if products := result.Products["vertical"]; len(products) != 1 || products[0].Size() != 2 {
+		t.Fatalf(`Unexpected products of the vertical bin`)
+	}
+
+	// Only the horizontal bar has a glue the tiles of the last bin bind to, the vertical bar
+	// and the tile extending nothing are terminal as they are
+	products := result.Products["extended"]
+
+	if len(products) != 3 || products[0].Size() != 2 || products[1].Size() != 1 || products[2].Size() != 3 {
+		t.Fatalf(`Unexpected products of the extended bin`)
+	}
+
+	if !products[2].GetTileMap().Domain().Verify(NewShape([]Vec2Di{{0, 0}, {1, 0}, {2, 0}})).IsExact() {
+		t.Fatalf(`The horizontal bar was not extended`)
+	}
+}
+
+// Two bins each build a vertical domino, a third bin binds the dominoes side by side with two
+// glues of strength 1 at threshold 2
+func TestStagedAssemblyCombinesProducts(t *testing.T) {
+	system := StagedSystem{Bins: []StagedBin{
+		{
+			Name:      "left",
+			TileSet:   TileSet{"top": SquareGlues{NULL_GLUE, "p", "a", NULL_GLUE}},
+			Threshold: 1,
+			Seed:      TileMap{Vec2Di{0, 0}: SquareGlues{"a", "q", NULL_GLUE, NULL_GLUE}},
+		},
+		{
+			Name:      "right",
+			TileSet:   TileSet{"top": SquareGlues{NULL_GLUE, NULL_GLUE, "b", "p"}},
+			Threshold: 1,
+			Seed:      TileMap{Vec2Di{0, 0}: SquareGlues{"b", NULL_GLUE, NULL_GLUE, "q"}},
+		},
+		{
+			Name:      "square",
+			Threshold: 2,
+			Inputs:    []string{"left", "right"},
+		},
+	}}
+
+	result, err := system.Run()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	for _, name := range []string{"left", "right"} {
+		if products := result.Products[name]; len(products) != 1 || products[0].Size() != 2 {
+			t.Fatalf(`Unexpected products of the %s bin`, name)
+		}
+	}
+
+	products := result.Products["square"]
+
+	if len(products) != 1 || !products[0].GetTileMap().Domain().Verify(NewShape([]Vec2Di{{0, 0}, {1, 0}, {0, 1}, {1, 1}})).IsExact() {
+		t.Fatalf(`The dominoes were not combined`)
+	}
+
+	// A single glue of strength 1 is not enough for the dominoes to bind
+	system.Bins[0].Seed = TileMap{Vec2Di{0, 0}: SquareGlues{"a", NULL_GLUE, NULL_GLUE, NULL_GLUE}}
+
+	result, err = system.Run()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if products := result.Products["square"]; len(products) != 2 || products[0].Size() != 2 || products[1].Size() != 2 {
+		t.Fatalf(`Dominoes bound below the threshold`)
+	}
+}
+
+func TestStagedAssemblyValidation(t *testing.T) {
+	system := newStagedSystem()
+
+	if err := system.Validate(); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	// The bars of the first bin grow forever
+	system.MaxAssemblies = 10
+
+	if _, err := system.Run(); err == nil || err.Error() != `bin "horizontal": more than 10 assemblies` {
+		t.Fatalf(`Mixing did not stop after %d assemblies: %v`, system.MaxAssemblies, err)
+	}
+
+	system.Bins[0].Inputs = []string{"extended"}
+
+	if err := system.Validate(); err == nil {
+		t.Fatalf(`Cycle not detected`)
+	}
+
+	system.Bins[0].Inputs = []string{"unknown"}
+
+	if err := system.Validate(); err == nil {
+		t.Fatalf(`Unknown input not detected`)
+	}
+
+	system.Bins[0].Inputs = nil
+	system.Bins[0].Seed = nil
+	system.Bins[0].TileSet = nil
+
+	if err := system.Validate(); err == nil {
+		t.Fatalf(`Bin with nothing to grow not detected`)
+	}
+}
+
+// A row grows one tile per step and two tile types fit its fourth position: the conflict is
+// reported even when it comes at the step checking that the assembly is terminal
+func TestGrowToTerminalConflict(t *testing.T) {
+	tileSet := TileSet{
+		"a": SquareGlues{NULL_GLUE, "b", NULL_GLUE, "a"},
+		"b": SquareGlues{NULL_GLUE, "c", NULL_GLUE, "b"},
+		"c": SquareGlues{NULL_GLUE, "d", NULL_GLUE, "c"},
+		"d": SquareGlues{NULL_GLUE, NULL_GLUE, NULL_GLUE, "d"},
+		"e": SquareGlues{"x", NULL_GLUE, NULL_GLUE, "d"},
+	}
+	seed := TileMap{Vec2Di{0, 0}: SquareGlues{NULL_GLUE, "a", NULL_GLUE, NULL_GLUE}}
+
+	for _, maxSteps := range []int{0, 3, 10} {
+		assembly := NewAssembly(tileSet, seed, 1)
+
+		if err := growToTerminal(&assembly, maxSteps); err == nil || assembly.Size() != 4 {
+			t.Fatalf(`%d steps: no conflict reported, %d tiles`, maxSteps, assembly.Size())
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package tamtam
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+type AssemblyStatistics struct {
+	Size int `json:"size"`
+	// Bounding box of the tiles, see TileMap.BoundingBox
+	LowerLeft  Vec2Di `json:"lower_left"`
+	UpperRight Vec2Di `json:"upper_right"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	// Number of tiles of each type, by tile name. Tiles which are not in the tile set are counted under their glues, see unnamedTileKey
+	TileCounts map[string]int `json:"tile_counts"`
+	// Number of tile sides carrying each (non null) glue
+	GlueUsage map[string]int `json:"glue_usage"`
+	// Number of empty positions where growth can happen
+	FrontierSize int `json:"frontier_size"`
+	// Rows and columns of the bounding box with no empty position
+	FullRows    int `json:"full_rows"`
+	FullColumns int `json:"full_columns"`
+	Steps       int `json:"steps"`
+	// Pairs of adjacent tiles whose facing glues differ
+	Mismatches int `json:"mismatches"`
+	// Empty positions enclosed by tiles, i.e. that cannot be reached from outside the bounding box by empty positions
+	Holes int `json:"holes"`
+	// Tiles made unstable by negative glues, see TileAssembly.UnstableTiles
+	Unstable int `json:"unstable"`
+}
+
+func (assembly TileAssembly) Statistics() (stats AssemblyStatistics) {
+	tileMap := assembly.tileMap
+	tileNames := assembly.GetOrientedTileSet().TileNames()
+
+	stats.Size = len(tileMap)
+	stats.LowerLeft, stats.UpperRight = tileMap.BoundingBox()
+	stats.TileCounts = make(map[string]int)
+	stats.GlueUsage = make(map[string]int)
+	stats.FrontierSize = assembly.emptyPositionsAboveThreshold.Len()
+	stats.Steps = assembly.steps
+	stats.Unstable = len(assembly.UnstableTiles())
+
+	if len(tileMap) == 0 {
+		return stats
+	}
+
+	stats.Width = stats.UpperRight[0] - stats.LowerLeft[0] + 1
+	stats.Height = stats.UpperRight[1] - stats.LowerLeft[1] + 1
+
+	tilesPerRow := make(map[int]int)
+	tilesPerColumn := make(map[int]int)
+	mismatchingSides := 0
+
+	for pos, tile := range tileMap {
+		if state, ok := assembly.signalStates[pos]; ok {
+			// The glues of signal tiles change as signals fire
+			stats.TileCounts[state.Type] += 1
+		} else if name, ok := tileNames[tile]; ok {
+			stats.TileCounts[name] += 1
+		} else {
+			stats.TileCounts[unnamedTileKey(tile)] += 1
+		}
+
+		for _, glue := range tile {
+			if glue != NULL_GLUE {
+				stats.GlueUsage[glue] += 1
+			}
+		}
+
+		tilesPerRow[pos[1]] += 1
+		tilesPerColumn[pos[0]] += 1
+
+		// Each pair is seen from both of its tiles
+		neighbors := assembly.neighbors(pos)
+		for side := 0; side < assembly.lattice.Sides(); side += 1 {
+			if neighbor, ok := tileMap[neighbors[side]]; ok && neighbor[assembly.lattice.Opposite(side)] != tile[side] {
+				mismatchingSides += 1
+			}
+		}
+	}
+
+	stats.Mismatches = mismatchingSides / 2
+
+	for _, count := range tilesPerRow {
+		if count == stats.Width {
+			stats.FullRows += 1
+		}
+	}
+
+	for _, count := range tilesPerColumn {
+		if count == stats.Height {
+			stats.FullColumns += 1
+		}
+	}
+
+	stats.Holes = countHoles(tileMap, assembly.lattice, stats.LowerLeft, stats.UpperRight)
+
+	return stats
+}
+
+// Key of tiles which are not in the tile set, such as "[- 0 - -]", - being the null glue
+func unnamedTileKey(tile SquareGlues) string {
+	glues := make([]string, tile.encodedSides())
+	for i, glue := range tile[:len(glues)] {
+		glues[i] = glue
+		if glue == NULL_GLUE {
+			glues[i] = DSL_NULL_GLUE
+		}
+	}
+	return "[" + strings.Join(glues, " ") + "]"
+}
+
+// Counts the empty positions of the bounding box which are not connected to its outside
+func countHoles(tileMap TileMap, lattice Lattice, lowerLeft Vec2Di, upperRight Vec2Di) int {
+	// Flooding empty positions from the ring around the bounding box
+	inside := func(pos Vec2Di) bool {
+		return pos[0] >= lowerLeft[0]-1 && pos[0] <= upperRight[0]+1 && pos[1] >= lowerLeft[1]-1 && pos[1] <= upperRight[1]+1
+	}
+
+	start := Vec2Di{lowerLeft[0] - 1, lowerLeft[1] - 1}
+	reached := map[Vec2Di]bool{start: true}
+	toVisit := []Vec2Di{start}
+
+	for len(toVisit) > 0 {
+		pos := toVisit[len(toVisit)-1]
+		toVisit = toVisit[:len(toVisit)-1]
+
+		neighbors := lattice.Neighbors(pos)
+		for _, nei := range neighbors[:lattice.Sides()] {
+			if _, filled := tileMap[nei]; !filled && !reached[nei] && inside(nei) {
+				reached[nei] = true
+				toVisit = append(toVisit, nei)
+			}
+		}
+	}
+
+	// Positions of the enlarged box are either tiles, reached or holes
+	area := (upperRight[0] - lowerLeft[0] + 3) * (upperRight[1] - lowerLeft[1] + 3)
+	return area - len(tileMap) - len(reached)
+}
+
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sortNames(keys)
+	return keys
+}
+
+// Prints the statistics as a two column table
+func (stats AssemblyStatistics) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Tiles\t%d\n", stats.Size)
+	fmt.Fprintf(tw, "Bounding box\t%v to %v (%dx%d)\n", stats.LowerLeft, stats.UpperRight, stats.Width, stats.Height)
+	fmt.Fprintf(tw, "Full rows\t%d\n", stats.FullRows)
+	fmt.Fprintf(tw, "Full columns\t%d\n", stats.FullColumns)
+	fmt.Fprintf(tw, "Frontier size\t%d\n", stats.FrontierSize)
+	fmt.Fprintf(tw, "Growth steps\t%d\n", stats.Steps)
+	fmt.Fprintf(tw, "Mismatches\t%d\n", stats.Mismatches)
+	fmt.Fprintf(tw, "Holes\t%d\n", stats.Holes)
+	fmt.Fprintf(tw, "Unstable\t%d\n", stats.Unstable)
+
+	fmt.Fprintln(tw, "\nTile type\tCount")
+	for _, name := range sortedKeys(stats.TileCounts) {
+		fmt.Fprintf(tw, "%s\t%d\n", name, stats.TileCounts[name])
+	}
+
+	fmt.Fprintln(tw, "\nGlue\tSides")
+	for _, glue := range sortedKeys(stats.GlueUsage) {
+		fmt.Fprintf(tw, "%s\t%d\n", glue, stats.GlueUsage[glue])
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,54 @@
+package tamtam
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStatistics(t *testing.T) {
+	assembly := grownCrtAssembly(t, 6)
+	stats := assembly.Statistics()
+
+	if stats.Size != 48 || stats.Width != 7 || stats.Height != 7 || stats.FullRows != 6 || stats.FullColumns != 6 {
+		t.Fatalf(`Unexpected statistics %+v`, stats)
+	}
+
+	if stats.Holes != 0 || stats.Mismatches != 0 || stats.FrontierSize != 0 || stats.Steps != assembly.GetSteps() {
+		t.Fatalf(`Unexpected statistics %+v`, stats)
+	}
+
+	total := 0
+	for _, count := range stats.TileCounts {
+		total += count
+	}
+
+	if total != stats.Size {
+		t.Fatalf(`Tile counts add up to %d instead of %d`, total, stats.Size)
+	}
+
+	// Replacing a tile in the middle by a tile matching none of its neighbors
+	assembly.RemoveTile(Vec2Di{2, 2})
+	stats = assembly.Statistics()
+
+	if stats.Holes != 1 || stats.FrontierSize != 1 || stats.FullRows != 5 || stats.FullColumns != 5 {
+		t.Fatalf(`Unexpected statistics after removal %+v`, stats)
+	}
+
+	assembly.AddTile(Vec2Di{2, 2}, SquareGlues{"x", "x", "x", "x"})
+	stats = assembly.Statistics()
+
+	if stats.Holes != 0 || stats.Mismatches != 4 || stats.TileCounts["[x x x x]"] != 1 || stats.GlueUsage["x"] != 4 {
+		t.Fatalf(`Unexpected statistics after adding a mismatching tile %+v`, stats)
+	}
+
+	var table bytes.Buffer
+
+	if err := stats.WriteTable(&table); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !strings.Contains(table.String(), "Mismatches     4\n") {
+		t.Fatalf(`Unexpected table %q`, table.String())
+	}
+}
@@ -3,102 +3,354 @@ package tamtam
 import (
 	"encoding/json"
 	"errors"
+	"math/rand"
+	"reflect"
+	"sort"
 )
 
+// Number of random positions GrowAsync tries before going through the whole frontier
+const ASYNC_RANDOM_TRIES = 16
+
 type PosAndTile struct {
-	Pos  Vec2Di
-	Tile SquareGlues
+	Pos  Vec2Di      `json:"pos"`
+	Tile SquareGlues `json:"tile"`
 }
 
 type TileAssembly struct {
 	TileSet                      TileSet
 	tileMap                      TileMap
 	threshold                    int
-	emptyPositionsAboveThreshold map[Vec2Di]bool
-	newlyAddedTiles              []PosAndTile
+	glueStrengths                GlueStrengths
+	emptyPositionsAboveThreshold positionSet
+	observers                    []AssemblyObserver
+	// Number of growth steps performed since the assembly was created
+	steps        int
+	randomSource *splitMix64Source
+	rng          *rand.Rand
+	geometry     Geometry
+	lattice      Lattice
+	orientations Orientations
+	// Every orientation of the tile types and the signal tiles with their initial glues,
+	// unused for fixed orientations without signal tiles
+	placeableTileSet TileSet
+	signalTileSet    SignalTileSet
+	// Signal tile type of each initial glues
+	signalTypes map[Glues]string
+	// States of the placed signal tiles
+	signalStates map[Vec2Di]SignalState
+	// Concentrations weighing the choice between matching tiles in asynchronous growth
+	concentrations TileConcentrations
+	detachment     Detachment
+	// Positions of the tiles placed by growth, which detach when a detachment leaves them
+	// below the threshold, only kept with DETACHMENT_UNSTABLE
+	grownTiles Shape
+}
+
+// Returns nil for the plane so that it is omitted from JSON encodings
+func (assembly TileAssembly) encodedGeometry() *Geometry {
+	if assembly.geometry.Kind == GEOMETRY_PLANE {
+		return nil
+	}
+	return &assembly.geometry
 }
 
 func (assembly TileAssembly) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		TileSet   TileSet `json:"tile_set"`
-		TileMap   TileMap `json:"tile_map"`
-		Threshold int     `json:"threshold"`
+		TileSet       TileSet       `json:"tile_set"`
+		GlueStrengths GlueStrengths `json:"glue_strengths,omitempty"`
+		TileMap       TileMap       `json:"tile_map"`
+		Threshold     int           `json:"threshold"`
+		GrowthModel
+		SignalStates []PosAndSignalState `json:"signal_states,omitempty"`
 	}{
-		TileSet:   assembly.TileSet,
-		TileMap:   assembly.tileMap,
-		Threshold: assembly.threshold,
+		TileSet:       assembly.TileSet,
+		GlueStrengths: assembly.glueStrengths,
+		TileMap:       assembly.tileMap,
+		Threshold:     assembly.threshold,
+		GrowthModel:   assembly.GetGrowthModel(),
+		SignalStates:  assembly.GetSignalStates(),
 	})
 }
 
 func (assembly *TileAssembly) UnmarshalJSON(b []byte) error {
-
-	var rawAssembly struct {
-		TileSet   TileSet `json:"tile_set"`
-		TileMap   TileMap `json:"tile_map"`
-		Threshold int     `json:"threshold"`
-	}
-	err := json.Unmarshal(b, &rawAssembly)
+	var system TileSystem
+	err := json.Unmarshal(b, &system)
 
 	if err != nil {
 		return err
 	}
 
-	*assembly = NewAssembly(rawAssembly.TileSet, rawAssembly.TileMap, rawAssembly.Threshold)
+	*assembly, err = system.NewAssembly()
 
-	return nil
+	return err
 }
 
 func NewAssembly(tileSet TileSet, initialTiles map[Vec2Di]SquareGlues, threshold int) (assembly TileAssembly) {
+	return NewAssemblyWithGlueStrengths(tileSet, nil, initialTiles, threshold)
+}
+
+// Creates an assembly where glues bind with the given strengths instead of strength 1
+func NewAssemblyWithGlueStrengths(tileSet TileSet, glueStrengths GlueStrengths, initialTiles map[Vec2Di]SquareGlues, threshold int) (assembly TileAssembly) {
 	assembly.TileSet = tileSet
+	assembly.glueStrengths = glueStrengths
 	assembly.threshold = threshold
 
 	assembly.tileMap = make(map[Vec2Di]SquareGlues)
-	assembly.emptyPositionsAboveThreshold = make(map[Vec2Di]bool)
+	assembly.emptyPositionsAboveThreshold = newPositionSet()
+	assembly.SetRandomSeed(0)
 
-	for pos, tile := range initialTiles {
-		assembly.AddTile(pos, tile)
+	// Adding tiles in a fixed order so that the order of the frontier does not depend on map iteration
+	for _, pos := range TileMap(initialTiles).SortedPositions() {
+		assembly.AddTile(pos, initialTiles[pos])
 	}
 
 	return assembly
 }
 
+// Sets the space in which the assembly grows, meant to be called before growth. On a torus,
+// tiles outside of the fundamental rectangle are wrapped into it. The frontier is recomputed.
+func (assembly *TileAssembly) SetGeometry(geometry Geometry) error {
+	if err := geometry.Validate(); err != nil {
+		return err
+	}
+
+	if err := geometry.checkLattice(assembly.lattice); err != nil {
+		return err
+	}
+
+	assembly.geometry = geometry
+
+	tileMap := make(TileMap)
+	for _, pos := range assembly.tileMap.SortedPositions() {
+		tileMap[geometry.Normalize(pos)] = assembly.tileMap[pos]
+	}
+	assembly.tileMap = tileMap
+
+	assembly.rebuildFrontier()
+
+	return nil
+}
+
+func (assembly TileAssembly) GetGeometry() Geometry {
+	return assembly.geometry
+}
+
+// Sets the lattice of the assembly, meant to be called before growth. The tiles of the
+// tile set and of the assembly must fit the lattice. The frontier is recomputed.
+func (assembly *TileAssembly) SetLattice(lattice Lattice) error {
+	if err := lattice.Validate(); err != nil {
+		return err
+	}
+
+	if err := lattice.checkTiles(assembly.TileSet, assembly.tileMap); err != nil {
+		return err
+	}
+
+	if err := assembly.geometry.checkLattice(lattice); err != nil {
+		return err
+	}
+
+	if lattice != LATTICE_SQUARE && assembly.orientations != ORIENTATIONS_FIXED {
+		return errors.New("only square tiles can be rotated")
+	}
+
+	if lattice != assembly.lattice && len(assembly.signalTileSet) > 0 {
+		return errors.New("the lattice must be set before the signal tiles")
+	}
+
+	assembly.lattice = lattice
+	assembly.rebuildFrontier()
+
+	return nil
+}
+
+func (assembly TileAssembly) GetLattice() Lattice {
+	return assembly.lattice
+}
+
+// Lets the tile types be placed in other orientations, see TileSet.WithOrientations.
+// Only square tiles can be rotated, and tile names must not hold ORIENTATION_SEPARATOR.
+func (assembly *TileAssembly) SetOrientations(orientations Orientations) error {
+	if err := orientations.Validate(); err != nil {
+		return err
+	}
+
+	if assembly.lattice != LATTICE_SQUARE && orientations != ORIENTATIONS_FIXED {
+		return errors.New("only square tiles can be rotated")
+	}
+
+	if orientations != ORIENTATIONS_FIXED && len(assembly.signalTileSet) > 0 {
+		return errors.New("signal tiles cannot be rotated")
+	}
+
+	if err := orientations.checkTileNames(assembly.TileSet); err != nil {
+		return err
+	}
+
+	assembly.orientations = orientations
+	assembly.placeableTileSet = assembly.TileSet.WithOrientations(orientations)
+
+	return nil
+}
+
+func (assembly TileAssembly) GetOrientations() Orientations {
+	return assembly.orientations
+}
+
+// Returns the tile types in every orientation in which they can be placed, named as
+// with OrientedName, and the signal tiles with their initial glues. It is the tile set
+// itself when orientations are fixed and there are no signal tiles.
+func (assembly TileAssembly) GetOrientedTileSet() TileSet {
+	if assembly.orientations == ORIENTATIONS_FIXED && len(assembly.signalTileSet) == 0 {
+		return assembly.TileSet
+	}
+	return assembly.placeableTileSet
+}
+
+// Recomputes the positions where growth can happen from the tiles
+func (assembly *TileAssembly) rebuildFrontier() {
+	assembly.emptyPositionsAboveThreshold = newPositionSet()
+	for _, pos := range assembly.tileMap.SortedPositions() {
+		assembly.addNeighborsToFrontier(pos)
+	}
+}
+
 func (assembly TileAssembly) Size() int {
 	return len(assembly.tileMap)
 }
 
+// Returns the tile system whose initial tiles are the current tiles of the assembly
+func (assembly TileAssembly) GetTileSystem() TileSystem {
+	return TileSystem{TileSet: assembly.TileSet, GlueStrengths: assembly.glueStrengths, InitialTiles: assembly.tileMap, Threshold: assembly.threshold, GrowthModel: assembly.GetGrowthModel(), SignalStates: assembly.GetSignalStates()}
+}
+
+// Returns the number of successful calls to GrowSync and GrowAsync
+func (assembly TileAssembly) GetSteps() int {
+	return assembly.steps
+}
+
+func (assembly TileAssembly) GetThreshold() int {
+	return assembly.threshold
+}
+
+func (assembly TileAssembly) GetGlueStrengths() GlueStrengths {
+	return assembly.glueStrengths
+}
+
+// Returns the tiles of the assembly, the returned map must not be modified
+func (assembly TileAssembly) GetTileMap() TileMap {
+	return assembly.tileMap
+}
+
+// Returns the neighbors of the position on the lattice, wrapping around on a torus.
+// Only the first Sides() neighbors of the lattice are set.
+func (assembly TileAssembly) neighbors(pos Vec2Di) (neighbors [MAX_SIDES]Vec2Di) {
+	neighbors = assembly.lattice.Neighbors(pos)
+	for side := 0; side < assembly.lattice.Sides(); side += 1 {
+		neighbors[side] = assembly.geometry.Normalize(neighbors[side])
+	}
+	return neighbors
+}
+
 func (assembly TileAssembly) neighboringGlues(pos Vec2Di) (glues SquareGlues) {
-	for i, nei := range pos.Neighbors() {
-		if val, ok := assembly.tileMap[nei]; ok {
-			glues[i] = val[(i+2)%4]
-		} else {
-			glues[i] = NULL_GLUE
+	neighbors := assembly.neighbors(pos)
+	for side := 0; side < assembly.lattice.Sides(); side += 1 {
+		if val, ok := assembly.tileMap[neighbors[side]]; ok {
+			glues[side] = val[assembly.lattice.Opposite(side)]
 		}
 	}
 	return glues
 }
 
+// Whether a tile matching the glues of positive strength around the position would reach the threshold
 func (assembly TileAssembly) isPosAboveThreshold(pos Vec2Di) bool {
 	var count = 0
 	for _, glue := range assembly.neighboringGlues(pos) {
-		if glue != NULL_GLUE {
-			count += 1
+		if strength := assembly.glueStrengths.Strength(glue); strength > 0 {
+			count += strength
 		}
 	}
 	return count >= assembly.threshold
 }
 
+// Returns the tile types that can be placed at the position
+func (assembly TileAssembly) matchTiles(pos Vec2Di) []SquareGlues {
+	return assembly.GetOrientedTileSet().MatchTilesWithStrengths(assembly.neighboringGlues(pos), assembly.glueStrengths, assembly.threshold)
+}
+
+// Adds the empty neighbors of the position where growth can happen to the frontier
+func (assembly *TileAssembly) addNeighborsToFrontier(pos Vec2Di) {
+	neighbors := assembly.neighbors(pos)
+	for _, nei := range neighbors[:assembly.lattice.Sides()] {
+		if _, ok := assembly.tileMap[nei]; !ok && assembly.geometry.Contains(nei) && assembly.isPosAboveThreshold(nei) {
+			assembly.emptyPositionsAboveThreshold.Add(nei)
+		}
+	}
+}
+
 func (assembly *TileAssembly) AddTile(pos Vec2Di, tile SquareGlues) {
+	pos = assembly.geometry.Normalize(pos)
 	assembly.tileMap[pos] = tile
+	delete(assembly.signalStates, pos)
+	delete(assembly.grownTiles, pos)
+
+	assembly.emptyPositionsAboveThreshold.Remove(pos)
+	assembly.addNeighborsToFrontier(pos)
+
+	assembly.notifyTileAdded(pos, tile)
+}
+
+// Removes the tile at the given position, returns false if there was no tile there.
+// The removed position and its empty neighbors are put back in, or taken out of,
+// the set of positions where growth can happen.
+func (assembly *TileAssembly) RemoveTile(pos Vec2Di) bool {
+	pos = assembly.geometry.Normalize(pos)
+	tile, ok := assembly.tileMap[pos]
 
-	assembly.newlyAddedTiles = append(assembly.newlyAddedTiles, PosAndTile{Pos: pos, Tile: tile})
-	delete(assembly.emptyPositionsAboveThreshold, pos)
+	if !ok {
+		return false
+	}
+
+	delete(assembly.tileMap, pos)
+	delete(assembly.signalStates, pos)
+	delete(assembly.grownTiles, pos)
+
+	if assembly.geometry.Contains(pos) && assembly.isPosAboveThreshold(pos) {
+		assembly.emptyPositionsAboveThreshold.Add(pos)
+	}
 
-	for _, nei := range pos.Neighbors() {
-		if _, ok := assembly.tileMap[nei]; assembly.isPosAboveThreshold(nei) && !ok {
-			assembly.emptyPositionsAboveThreshold[nei] = true
+	neighbors := assembly.neighbors(pos)
+	for _, nei := range neighbors[:assembly.lattice.Sides()] {
+		if _, ok := assembly.tileMap[nei]; !ok && !assembly.isPosAboveThreshold(nei) {
+			assembly.emptyPositionsAboveThreshold.Remove(nei)
 		}
 	}
 
+	assembly.notifyTileRemoved(pos, tile)
+
+	return true
+}
+
+// Removes the tiles at the given positions, returns the number of tiles that were removed
+func (assembly *TileAssembly) RemoveTiles(positions []Vec2Di) (count int) {
+	for _, pos := range positions {
+		if assembly.RemoveTile(pos) {
+			count += 1
+		}
+	}
+	return count
+}
+
+// Removes all the tiles in the rectangle delimited by the lower left and upper right
+// corners (both included), returns the number of tiles that were removed
+func (assembly *TileAssembly) ClearRegion(lowerLeft Vec2Di, upperRight Vec2Di) int {
+	var positions []Vec2Di
+	for pos := range assembly.tileMap {
+		if pos[0] >= lowerLeft[0] && pos[0] <= upperRight[0] && pos[1] >= lowerLeft[1] && pos[1] <= upperRight[1] {
+			positions = append(positions, pos)
+		}
+	}
+	return assembly.RemoveTiles(positions)
 }
 
 // Performs a synchronous growth step
@@ -106,8 +358,12 @@ func (assembly *TileAssembly) GrowSync(directed bool) (bool, error) {
 
 	var toAdd []PosAndTile
 
-	for pos := range assembly.emptyPositionsAboveThreshold {
-		var matches = assembly.TileSet.MatchTiles(assembly.neighboringGlues(pos), assembly.threshold)
+	for _, pos := range assembly.emptyPositionsAboveThreshold.Positions() {
+		var matches = assembly.matchTiles(pos)
+
+		if len(matches) > 1 {
+			assembly.notifyConflict(pos, matches)
+		}
 
 		if len(matches) > 1 && directed {
 			return false, errors.New("two different tiles fit the same position, this is not allowed in directed setting")
@@ -119,23 +375,99 @@ func (assembly *TileAssembly) GrowSync(directed bool) (bool, error) {
 	}
 
 	for _, posAndTile := range toAdd {
-		assembly.AddTile(posAndTile.Pos, posAndTile.Tile)
+		assembly.placeTile(posAndTile.Pos, posAndTile.Tile)
 	}
 
 	var anyGrowth = len(toAdd) >= 1
+	if anyGrowth {
+		assembly.steps += 1
+		assembly.notifyStepComplete()
+	}
 	return anyGrowth, nil
 }
 
-func (assembly TileAssembly) IsEqualTo(otherAssembly TileAssembly) bool {
-	return assembly.threshold == otherAssembly.threshold && assembly.TileSet.IsEqualTo(otherAssembly.TileSet) && assembly.tileMap.IsEqualTo(otherAssembly.tileMap)
+// Seeds the random number generator used by asynchronous growth
+func (assembly *TileAssembly) SetRandomSeed(seed int64) {
+	assembly.randomSource = newSplitMix64Source(seed)
+	assembly.rng = rand.New(assembly.randomSource)
 }
 
-// Returns the tiles (with their position) that were added at the last round of growth
-func (assembly TileAssembly) GetNewlyAddedTiles() []PosAndTile {
+// Orders tiles by their glues so that random choices among matches only depend on the random generator
+func sortTiles(tiles []SquareGlues) {
+	sort.Slice(tiles, func(i, j int) bool {
+		for side := 0; side < MAX_SIDES; side += 1 {
+			if tiles[i][side] != tiles[j][side] {
+				return tiles[i][side] < tiles[j][side]
+			}
+		}
+		return false
+	})
+}
 
-	return assembly.newlyAddedTiles
+// Returns a position of the frontier, chosen at random, where at least one tile fits
+// together with the fitting tiles, ok is false if no tile fits anywhere
+func (assembly *TileAssembly) randomGrowthPosition() (pos Vec2Di, matches []SquareGlues, ok bool) {
+	frontier := assembly.emptyPositionsAboveThreshold
+
+	for try := 0; try < ASYNC_RANDOM_TRIES && frontier.Len() > 0; try += 1 {
+		pos = frontier.At(assembly.rng.Intn(frontier.Len()))
+		matches = assembly.matchTiles(pos)
+		if len(matches) > 0 {
+			return pos, matches, true
+		}
+	}
+
+	// Most of the frontier is blocked, going through all of it in random order
+	for _, index := range assembly.rng.Perm(frontier.Len()) {
+		pos = frontier.At(index)
+		matches = assembly.matchTiles(pos)
+		if len(matches) > 0 {
+			return pos, matches, true
+		}
+	}
+
+	return pos, nil, false
 }
 
-func (assembly *TileAssembly) FlushNewlyAddedTiles() {
-	assembly.newlyAddedTiles = []PosAndTile{}
+// Whether a tile fits a position of the frontier, without growing the assembly
+func (assembly TileAssembly) canGrow() bool {
+	for _, pos := range assembly.emptyPositionsAboveThreshold.Positions() {
+		if len(assembly.matchTiles(pos)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Performs an asynchronous growth step: one tile is added at a random position of the frontier
+func (assembly *TileAssembly) GrowAsync(directed bool) (bool, error) {
+	pos, matches, ok := assembly.randomGrowthPosition()
+
+	if !ok {
+		return false, nil
+	}
+
+	sortTiles(matches)
+
+	if len(matches) > 1 {
+		assembly.notifyConflict(pos, matches)
+	}
+
+	if len(matches) > 1 && directed {
+		return false, errors.New("two different tiles fit the same position, this is not allowed in directed setting")
+	}
+
+	if len(assembly.concentrations) > 0 {
+		assembly.placeTile(pos, assembly.chooseByConcentration(matches))
+	} else {
+		assembly.placeTile(pos, matches[assembly.rng.Intn(len(matches))])
+	}
+	assembly.steps += 1
+	assembly.notifyStepComplete()
+
+	return true, nil
+}
+
+func (assembly TileAssembly) IsEqualTo(otherAssembly TileAssembly) bool {
+	return assembly.threshold == otherAssembly.threshold && assembly.GetGrowthModel().IsEqualTo(otherAssembly.GetGrowthModel()) && reflect.DeepEqual(assembly.GetSignalStates(), otherAssembly.GetSignalStates()) && assembly.glueStrengths.IsEqualTo(otherAssembly.glueStrengths) && assembly.TileSet.IsEqualTo(otherAssembly.TileSet) && assembly.tileMap.IsEqualTo(otherAssembly.tileMap)
 }
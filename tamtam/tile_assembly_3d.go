@@ -0,0 +1,225 @@
+package tamtam
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+)
+
+// Assembly of cubes, the 3D counterpart of TileAssembly. Tile types are cubes whose
+// six glues are given as CubeGlues, so that tile sets are the ones of 2D assemblies.
+type TileAssembly3D struct {
+	TileSet                      TileSet
+	tileMap                      TileMap3D
+	threshold                    int
+	glueStrengths                GlueStrengths
+	emptyPositionsAboveThreshold positionSet3D
+	// Number of growth steps performed since the assembly was created
+	steps        int
+	randomSource *splitMix64Source
+	rng          *rand.Rand
+}
+
+// Number of dimensions written in JSON encodings of 3D assemblies, which tells them apart from 2D ones
+const DIMENSIONS_3D = 3
+
+func (assembly TileAssembly3D) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Dimensions    int           `json:"dimensions"`
+		TileSet       TileSet       `json:"tile_set"`
+		GlueStrengths GlueStrengths `json:"glue_strengths,omitempty"`
+		TileMap       TileMap3D     `json:"tile_map"`
+		Threshold     int           `json:"threshold"`
+	}{
+		Dimensions:    DIMENSIONS_3D,
+		TileSet:       assembly.TileSet,
+		GlueStrengths: assembly.glueStrengths,
+		TileMap:       assembly.tileMap,
+		Threshold:     assembly.threshold,
+	})
+}
+
+func (assembly *TileAssembly3D) UnmarshalJSON(b []byte) error {
+
+	var rawAssembly struct {
+		Dimensions    int           `json:"dimensions"`
+		TileSet       TileSet       `json:"tile_set"`
+		GlueStrengths GlueStrengths `json:"glue_strengths"`
+		TileMap       TileMap3D     `json:"tile_map"`
+		Threshold     int           `json:"threshold"`
+	}
+	err := json.Unmarshal(b, &rawAssembly)
+
+	if err != nil {
+		return err
+	}
+
+	if rawAssembly.Dimensions != DIMENSIONS_3D {
+		return errors.New("not a 3D assembly, \"dimensions\" must be 3")
+	}
+
+	*assembly = NewAssembly3D(rawAssembly.TileSet, rawAssembly.GlueStrengths, rawAssembly.TileMap, rawAssembly.Threshold)
+
+	return nil
+}
+
+func NewAssembly3D(tileSet TileSet, glueStrengths GlueStrengths, initialTiles TileMap3D, threshold int) (assembly TileAssembly3D) {
+	assembly.TileSet = tileSet
+	assembly.glueStrengths = glueStrengths
+	assembly.threshold = threshold
+
+	assembly.tileMap = make(TileMap3D)
+	assembly.emptyPositionsAboveThreshold = newPositionSet3D()
+	assembly.SetRandomSeed(0)
+
+	for _, pos := range initialTiles.SortedPositions() {
+		assembly.AddTile(pos, initialTiles[pos])
+	}
+
+	return assembly
+}
+
+func (assembly TileAssembly3D) Size() int {
+	return len(assembly.tileMap)
+}
+
+// Returns the number of successful calls to GrowSync and GrowAsync
+func (assembly TileAssembly3D) GetSteps() int {
+	return assembly.steps
+}
+
+func (assembly TileAssembly3D) GetThreshold() int {
+	return assembly.threshold
+}
+
+func (assembly TileAssembly3D) GetGlueStrengths() GlueStrengths {
+	return assembly.glueStrengths
+}
+
+// Returns the tiles of the assembly, the returned map must not be modified
+func (assembly TileAssembly3D) GetTileMap() TileMap3D {
+	return assembly.tileMap
+}
+
+func (assembly TileAssembly3D) neighboringGlues(pos Vec3Di) (glues CubeGlues) {
+	for i, nei := range pos.Neighbors() {
+		if val, ok := assembly.tileMap[nei]; ok {
+			glues[i] = val[cubeOpposite(i)]
+		}
+	}
+	return glues
+}
+
+// Whether a tile matching the glues of positive strength around the position would reach the threshold
+func (assembly TileAssembly3D) isPosAboveThreshold(pos Vec3Di) bool {
+	var count = 0
+	for _, glue := range assembly.neighboringGlues(pos) {
+		if strength := assembly.glueStrengths.Strength(glue); strength > 0 {
+			count += strength
+		}
+	}
+	return count >= assembly.threshold
+}
+
+// Returns the tile types that can be placed at the position
+func (assembly TileAssembly3D) matchTiles(pos Vec3Di) []CubeGlues {
+	return assembly.TileSet.MatchTilesWithStrengths(assembly.neighboringGlues(pos), assembly.glueStrengths, assembly.threshold)
+}
+
+func (assembly *TileAssembly3D) AddTile(pos Vec3Di, tile CubeGlues) {
+	assembly.tileMap[pos] = tile
+	assembly.emptyPositionsAboveThreshold.Remove(pos)
+
+	for _, nei := range pos.Neighbors() {
+		if _, ok := assembly.tileMap[nei]; !ok && assembly.isPosAboveThreshold(nei) {
+			assembly.emptyPositionsAboveThreshold.Add(nei)
+		}
+	}
+}
+
+// Performs a synchronous growth step
+func (assembly *TileAssembly3D) GrowSync(directed bool) (bool, error) {
+	type posAndTile3D struct {
+		pos  Vec3Di
+		tile CubeGlues
+	}
+
+	var toAdd []posAndTile3D
+
+	for _, pos := range assembly.emptyPositionsAboveThreshold.Positions() {
+		var matches = assembly.matchTiles(pos)
+
+		if len(matches) > 1 && directed {
+			return false, errors.New("two different tiles fit the same position, this is not allowed in directed setting")
+		}
+
+		for _, tile := range matches {
+			toAdd = append(toAdd, posAndTile3D{pos: pos, tile: tile})
+		}
+	}
+
+	for _, posAndTile := range toAdd {
+		assembly.AddTile(posAndTile.pos, posAndTile.tile)
+	}
+
+	var anyGrowth = len(toAdd) >= 1
+	if anyGrowth {
+		assembly.steps += 1
+	}
+	return anyGrowth, nil
+}
+
+// Seeds the random number generator used by asynchronous growth
+func (assembly *TileAssembly3D) SetRandomSeed(seed int64) {
+	assembly.randomSource = newSplitMix64Source(seed)
+	assembly.rng = rand.New(assembly.randomSource)
+}
+
+// Returns a random position of the frontier where a tile fits and the tiles fitting it,
+// see TileAssembly.randomGrowthPosition
+func (assembly *TileAssembly3D) randomGrowthPosition() (pos Vec3Di, matches []SquareGlues, ok bool) {
+	frontier := assembly.emptyPositionsAboveThreshold
+
+	for try := 0; try < ASYNC_RANDOM_TRIES && frontier.Len() > 0; try += 1 {
+		pos = frontier.At(assembly.rng.Intn(frontier.Len()))
+		matches = assembly.matchTiles(pos)
+		if len(matches) > 0 {
+			return pos, matches, true
+		}
+	}
+
+	// Most of the frontier is blocked, going through all of it in random order
+	for _, index := range assembly.rng.Perm(frontier.Len()) {
+		pos = frontier.At(index)
+		matches = assembly.matchTiles(pos)
+		if len(matches) > 0 {
+			return pos, matches, true
+		}
+	}
+
+	return pos, nil, false
+}
+
+// Performs an asynchronous growth step: one tile is added at a random position of the frontier
+// where a tile fits
+func (assembly *TileAssembly3D) GrowAsync(directed bool) (bool, error) {
+	pos, matches, ok := assembly.randomGrowthPosition()
+
+	if !ok {
+		return false, nil
+	}
+
+	if len(matches) > 1 && directed {
+		return false, errors.New("two different tiles fit the same position, this is not allowed in directed setting")
+	}
+
+	sortTiles(matches)
+	assembly.AddTile(pos, matches[assembly.rng.Intn(len(matches))])
+	assembly.steps += 1
+
+	return true, nil
+}
+
+func (assembly TileAssembly3D) IsEqualTo(otherAssembly TileAssembly3D) bool {
+	return assembly.threshold == otherAssembly.threshold && assembly.glueStrengths.IsEqualTo(otherAssembly.glueStrengths) && assembly.TileSet.IsEqualTo(otherAssembly.TileSet) && assembly.tileMap.IsEqualTo(otherAssembly.tileMap)
+}
@@ -0,0 +1,93 @@
+package tamtam
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// At threshold 2, cubes with glues on every side fill the box delimited by three arms
+// of length 3 along the x, y and z axes. The tiles of the x arm bind on their north and
+// up sides, so the box is 3 cubes wide, 4 deep and 4 high, the x arm being in its corner.
+func newCubeAssembly() TileAssembly3D {
+	filler := CubeGlues{"a", "a", "a", "a", "a", "a"}
+	tiles := make(TileMap3D)
+
+	for i := 1; i <= 3; i += 1 {
+		tiles[Vec3Di{i, 0, 0}] = CubeGlues{"a", NULL_GLUE, NULL_GLUE, NULL_GLUE, "a", NULL_GLUE}
+		tiles[Vec3Di{0, i, 0}] = CubeGlues{NULL_GLUE, "a", NULL_GLUE, NULL_GLUE, NULL_GLUE, NULL_GLUE}
+		tiles[Vec3Di{0, 0, i}] = CubeGlues{NULL_GLUE, "a", NULL_GLUE, NULL_GLUE, NULL_GLUE, NULL_GLUE}
+	}
+
+	return NewAssembly3D(TileSet{"filler": filler}, nil, tiles, 2)
+}
+
+func TestAssembly3D(t *testing.T) {
+	assembly := newCubeAssembly()
+
+	didGrow, err := assembly.GrowSync(true)
+
+	for didGrow && err == nil {
+		didGrow, err = assembly.GrowSync(true)
+	}
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if assembly.Size() != 3*4*4+6 {
+		t.Fatalf(`Assembly size %d != %d`, assembly.Size(), 3*4*4+6)
+	}
+
+	if lowerCorner, upperCorner := assembly.GetTileMap().BoundingBox(); lowerCorner != (Vec3Di{0, 0, 0}) || upperCorner != (Vec3Di{3, 3, 3}) {
+		t.Fatalf(`Unexpected bounding box %v %v`, lowerCorner, upperCorner)
+	}
+
+	heights := assembly.GetTileMap().Heights()
+
+	if len(heights) != 4 || heights[0] != 0 || heights[3] != 3 {
+		t.Fatalf(`Unexpected heights %v`, heights)
+	}
+
+	if slice := assembly.GetTileMap().Slice(2); len(slice) != 3*4+1 || slice[Vec2Di{3, 3}] != assembly.TileSet["filler"] {
+		t.Fatalf(`Unexpected slice %v`, slice)
+	}
+
+	asyncAssembly := newCubeAssembly()
+	asyncAssembly.SetRandomSeed(42)
+
+	for didGrow, err = asyncAssembly.GrowAsync(true); didGrow && err == nil; didGrow, err = asyncAssembly.GrowAsync(true) {
+	}
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if asyncAssembly.GetSteps() != 3*4*4-3 || !asyncAssembly.IsEqualTo(assembly) {
+		t.Fatalf(`Asynchronous growth ended with %d tiles after %d steps`, asyncAssembly.Size(), asyncAssembly.GetSteps())
+	}
+}
+
+func TestAssembly3DSerialization(t *testing.T) {
+	assembly := newCubeAssembly()
+	assembly.GrowSync(true)
+
+	b, err := json.Marshal(assembly)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	var decoded TileAssembly3D
+
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !decoded.IsEqualTo(assembly) {
+		t.Fatalf(`Decoded assembly differs from the original one`)
+	}
+
+	if err := json.Unmarshal([]byte(`{"tile_set": {}, "tile_map": {"[0,0]": ["a", "", "", ""]}, "threshold": 1}`), &decoded); err == nil {
+		t.Fatalf(`2D assembly decoded as a 3D one`)
+	}
+}
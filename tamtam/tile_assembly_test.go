@@ -67,3 +67,127 @@ func TestAssemblyAndSerialization(t *testing.T) {
 		t.Fatalf(`%v`, err)
 	}
 }
+
+// Grows the CRT scenario of TestAssemblyAndSerialization until no more growth is possible
+func grownCrtAssembly(t *testing.T, size int) TileAssembly {
+	tileSet, err := NewCrtTileSet(2, 11)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	var initialAssembly = make(map[Vec2Di]SquareGlues)
+
+	for i := 0; i < size; i += 1 {
+		initialAssembly[Vec2Di{-1, i}] = SquareGlues{NULL_GLUE, "0", NULL_GLUE, NULL_GLUE}
+	}
+
+	initialAssembly[Vec2Di{0, -1}] = SquareGlues{"1", NULL_GLUE, NULL_GLUE, NULL_GLUE}
+
+	for i := 0; i < size-1; i += 1 {
+		initialAssembly[Vec2Di{1 + i, -1}] = SquareGlues{"0", NULL_GLUE, NULL_GLUE, NULL_GLUE}
+	}
+
+	var assembly = NewAssembly(tileSet, initialAssembly, 2)
+
+	didGrow, err := assembly.GrowSync(true)
+
+	for didGrow && err == nil {
+		didGrow, err = assembly.GrowSync(true)
+	}
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	return assembly
+}
+
+// Testing that removing tiles keeps the frontier consistent: growing again
+// after removing a region must give back the original assembly
+func TestRemoveTilesAndRegrow(t *testing.T) {
+	SIZE := 10
+	assembly := grownCrtAssembly(t, SIZE)
+	original := grownCrtAssembly(t, SIZE)
+
+	changes := NewChangeLog()
+	assembly.AddObserver(changes)
+
+	// Clearing the upper right corner of the assembly: the removed positions
+	// only have input glues from the south and west so that regrowth is directed
+	removed := assembly.ClearRegion(Vec2Di{SIZE - 4, SIZE - 4}, Vec2Di{SIZE - 1, SIZE - 1})
+
+	if removed != 16 {
+		t.Fatalf(`Removed %d tiles instead of 16`, removed)
+	}
+
+	if !assembly.RemoveTile(Vec2Di{SIZE - 5, SIZE - 1}) {
+		t.Fatalf(`Could not remove tile at %v`, Vec2Di{SIZE - 5, SIZE - 1})
+	}
+
+	if assembly.RemoveTile(Vec2Di{-5, -5}) {
+		t.Fatalf(`Removed a tile at an empty position`)
+	}
+
+	if len(changes.GetRemovedTiles()) != 17 {
+		t.Fatalf(`%d tiles reported as removed instead of 17`, len(changes.GetRemovedTiles()))
+	}
+
+	if assembly.Size() != original.Size()-17 {
+		t.Fatalf(`Assembly size %d != %d`, assembly.Size(), original.Size()-17)
+	}
+
+	if !assembly.emptyPositionsAboveThreshold.Contains(Vec2Di{SIZE - 4, SIZE - 4}) {
+		t.Fatalf(`Position %v should be in the frontier`, Vec2Di{SIZE - 4, SIZE - 4})
+	}
+
+	if assembly.emptyPositionsAboveThreshold.Contains(Vec2Di{SIZE - 1, SIZE - 1}) {
+		t.Fatalf(`Position %v should not be in the frontier`, Vec2Di{SIZE - 1, SIZE - 1})
+	}
+
+	didGrow, err := assembly.GrowSync(true)
+
+	for didGrow && err == nil {
+		didGrow, err = assembly.GrowSync(true)
+	}
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !assembly.IsEqualTo(original) {
+		t.Fatalf(`Regrown assembly differs from the original one`)
+	}
+}
+
+// Testing that in the directed setting asynchronous growth reaches the
+// same terminal assembly as synchronous growth
+func TestGrowAsync(t *testing.T) {
+	SIZE := 10
+	synchronous := grownCrtAssembly(t, SIZE)
+
+	// Only keeping the seed of the synchronous assembly
+	seed := make(map[Vec2Di]SquareGlues)
+	for pos, tile := range synchronous.GetTileMap() {
+		if pos[0] == -1 || pos[1] == -1 {
+			seed[pos] = tile
+		}
+	}
+
+	assembly := NewAssembly(synchronous.TileSet, seed, 2)
+	assembly.SetRandomSeed(42)
+
+	didGrow, err := assembly.GrowAsync(true)
+
+	for didGrow && err == nil {
+		didGrow, err = assembly.GrowAsync(true)
+	}
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !assembly.IsEqualTo(synchronous) {
+		t.Fatalf(`Asynchronous and synchronous growth differ`)
+	}
+}
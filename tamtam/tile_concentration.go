@@ -0,0 +1,115 @@
+package tamtam
+
+// Tile concentrations (the probabilistic model): when several tile types fit the position
+// chosen by asynchronous growth, one of them is picked with a probability proportional to
+// its concentration instead of uniformly.
+
+import (
+	"fmt"
+	"math"
+)
+
+// Concentration of each tile type, by name, tile types that are not listed have concentration 1.
+// Rotated tiles have the concentration of their tile type.
+type TileConcentrations map[string]float64
+
+func (concentrations TileConcentrations) Concentration(name string) float64 {
+	if concentration, ok := concentrations[name]; ok {
+		return concentration
+	}
+	return 1
+}
+
+func (concentrations TileConcentrations) IsEqualTo(otherConcentrations TileConcentrations) bool {
+	for name, concentration := range concentrations {
+		if otherConcentrations.Concentration(name) != concentration {
+			return false
+		}
+	}
+	for name, concentration := range otherConcentrations {
+		if concentrations.Concentration(name) != concentration {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks that concentrations are positive and given for tile types or signal tiles
+func (concentrations TileConcentrations) validate(tileSet TileSet, signalTileSet SignalTileSet) error {
+	for name, concentration := range concentrations {
+		_, isTile := tileSet[name]
+		_, isSignalTile := signalTileSet[name]
+
+		if !isTile && !isSignalTile {
+			return fmt.Errorf("concentration of unknown tile type %q", name)
+		}
+
+		if !(concentration > 0) || math.IsInf(concentration, 1) {
+			return fmt.Errorf("the concentration of tile type %q must be positive, not %v", name, concentration)
+		}
+	}
+	return nil
+}
+
+// Sets the concentrations of the tile types, which weigh the choice between tile types
+// fitting the same position in asynchronous growth
+func (assembly *TileAssembly) SetTileConcentrations(concentrations TileConcentrations) error {
+	if err := concentrations.validate(assembly.TileSet, assembly.signalTileSet); err != nil {
+		return err
+	}
+
+	assembly.concentrations = concentrations
+
+	return nil
+}
+
+func (assembly TileAssembly) GetTileConcentrations() TileConcentrations {
+	return assembly.concentrations
+}
+
+// Returns the total concentration of the placeable tile types having each of the glues
+func (assembly TileAssembly) concentrationsOf(tiles []Glues) map[Glues]float64 {
+	weights := make(map[Glues]float64)
+	for _, tile := range tiles {
+		weights[tile] = 0
+	}
+
+	for name, tile := range assembly.GetOrientedTileSet() {
+		if _, ok := weights[tile]; !ok {
+			continue
+		}
+		if assembly.orientations != ORIENTATIONS_FIXED {
+			name, _, _ = ParseOrientedName(name)
+		}
+		weights[tile] += assembly.concentrations.Concentration(name)
+	}
+
+	return weights
+}
+
+// Picks one of the sorted matching tiles with a probability proportional to the total
+// concentration of the tile types having its glues
+func (assembly *TileAssembly) chooseByConcentration(matches []Glues) Glues {
+	weights := assembly.concentrationsOf(matches)
+
+	var total float64
+	for _, weight := range weights {
+		total += weight
+	}
+
+	r := assembly.rng.Float64() * total
+
+	for i, tile := range matches {
+		// Tile types with the same glues are matched once each, their weight is already summed
+		if i > 0 && matches[i-1] == tile {
+			continue
+		}
+		if r < weights[tile] {
+			return tile
+		}
+		r -= weights[tile]
+	}
+
+	// Rounding errors
+	return matches[len(matches)-1]
+}
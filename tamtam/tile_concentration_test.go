@@ -0,0 +1,110 @@
+package tamtam
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// The seed binds either a tile ending growth or a tile on which a last tile binds,
+// three times out of four with concentrations 3 and 1
+func newConcentrationSystem() TileSystem {
+	return TileSystem{
+		TileSet: TileSet{
+			"stop":   SquareGlues{NULL_GLUE, NULL_GLUE, NULL_GLUE, "a"},
+			"go":     SquareGlues{"b", NULL_GLUE, NULL_GLUE, "a"},
+			"on_top": SquareGlues{NULL_GLUE, NULL_GLUE, "b", NULL_GLUE},
+			"seed":   SquareGlues{NULL_GLUE, "a", NULL_GLUE, NULL_GLUE},
+		},
+		InitialTiles: TileMap{Vec2Di{0, 0}: SquareGlues{NULL_GLUE, "a", NULL_GLUE, NULL_GLUE}},
+		Threshold:    1,
+		GrowthModel:  GrowthModel{TileConcentrations: TileConcentrations{"stop": 3}},
+	}
+}
+
+func TestMonteCarlo(t *testing.T) {
+	result, err := RunMonteCarlo(newConcentrationSystem(), 400, 1, 0)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if result.Runs != 400 || result.Unfinished != 0 || result.SizeCounts[2]+result.SizeCounts[3] != 400 {
+		t.Fatalf(`Unexpected result %v`, result)
+	}
+
+	if result.SizeCounts[2] < 260 || result.SizeCounts[2] > 340 {
+		t.Fatalf(`%d runs out of 400 stopped at size 2, about 300 expected`, result.SizeCounts[2])
+	}
+
+	if len(result.Shapes) != 2 || len(result.Shapes[0].Positions) != 2 || result.Shapes[0].Runs != result.SizeCounts[2] {
+		t.Fatalf(`Unexpected shapes %v`, result.Shapes)
+	}
+
+	if again, _ := RunMonteCarlo(newConcentrationSystem(), 400, 1, 0); again.SizeCounts[2] != result.SizeCounts[2] {
+		t.Fatalf(`Runs with the same seeds differ`)
+	}
+
+	// Runs binding the stopping tile are terminal after their single step
+	if limited, _ := RunMonteCarlo(newConcentrationSystem(), 10, 1, 1); limited.Unfinished == 0 || limited.Unfinished == 10 {
+		t.Fatalf(`%d runs out of 10 stopped by the step limit`, limited.Unfinished)
+	}
+
+	// Two steps are exactly enough for every run to be terminal
+	if exact, _ := RunMonteCarlo(newConcentrationSystem(), 10, 1, 2); exact.Unfinished != 0 {
+		t.Fatalf(`%d runs terminal after their last step counted as unfinished`, exact.Unfinished)
+	}
+}
+
+func TestTileConcentrationsEncoding(t *testing.T) {
+	assembly, err := newConcentrationSystem().NewAssembly()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	b, err := json.Marshal(assembly)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	var decoded TileAssembly
+
+	if err := json.Unmarshal(b, &decoded); err != nil || !decoded.IsEqualTo(assembly) || decoded.GetTileConcentrations()["stop"] != 3 {
+		t.Fatalf(`Decoded assembly differs from the original one: %v`, err)
+	}
+
+	var buffer bytes.Buffer
+
+	if err := WriteXgrowTiles(&buffer, newConcentrationSystem()); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !strings.Contains(buffer.String(), "[3]") {
+		t.Fatalf(`No stoichiometry in %s`, buffer.String())
+	}
+
+	// Tiles are numbered in the order of their names
+	if reloaded, err := ReadXgrowTiles(&buffer); err != nil || reloaded.TileConcentrations.Concentration("4") != 3 || len(reloaded.TileConcentrations) != 1 {
+		t.Fatalf(`Unexpected concentrations %v, %v`, reloaded.TileConcentrations, err)
+	}
+
+	if err := WritePyTAS(&buffer, newConcentrationSystem()); err == nil {
+		t.Fatalf(`Concentrations written in the PyTAS format`)
+	}
+
+	system := newConcentrationSystem()
+	system.TileConcentrations = TileConcentrations{"stop": 0}
+
+	if err := system.Validate(); err == nil {
+		t.Fatalf(`Zero concentration accepted`)
+	}
+
+	system.TileConcentrations = TileConcentrations{"unknown": 1}
+
+	if err := system.Validate(); err == nil {
+		t.Fatalf(`Concentration of an unknown tile accepted`)
+	}
+}
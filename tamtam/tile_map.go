@@ -58,3 +58,25 @@ func (tiles TileMap) IsEqualTo(otherTiles TileMap) bool {
 
 	return true
 }
+
+// Returns the lower left and upper right corners of the smallest rectangle
+// containing all the tiles, both are [0,0] if there are no tiles
+func (tiles TileMap) BoundingBox() (lowerLeft Vec2Di, upperRight Vec2Di) {
+	first := true
+	for pos := range tiles {
+		if first {
+			lowerLeft, upperRight = pos, pos
+			first = false
+			continue
+		}
+		for i := 0; i < 2; i += 1 {
+			if pos[i] < lowerLeft[i] {
+				lowerLeft[i] = pos[i]
+			}
+			if pos[i] > upperRight[i] {
+				upperRight[i] = pos[i]
+			}
+		}
+	}
+	return lowerLeft, upperRight
+}
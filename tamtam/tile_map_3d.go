@@ -0,0 +1,127 @@
+package tamtam
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Glues of a cube: north, east, south, west, up, down. The first four are
+// the ones of the square seen from above.
+type CubeGlues = Glues
+
+type TileMap3D map[Vec3Di]CubeGlues
+
+func (tiles TileMap3D) MarshalJSON() ([]byte, error) {
+	var toMarshal map[string]CubeGlues = make(map[string]CubeGlues)
+	for pos, tile := range tiles {
+		marshaledPos, err := json.Marshal(pos)
+		if err != nil {
+			return nil, err
+		}
+		toMarshal[string(marshaledPos)] = tile
+	}
+	return json.Marshal(toMarshal)
+}
+
+func (tiles *TileMap3D) UnmarshalJSON(b []byte) error {
+	var marshaled map[string]CubeGlues
+	err := json.Unmarshal(b, &marshaled)
+
+	if err != nil {
+		return err
+	}
+
+	*tiles = make(TileMap3D)
+
+	for encodedPos, tile := range marshaled {
+		var pos Vec3Di
+
+		err := json.Unmarshal([]byte(encodedPos), &pos)
+
+		if err != nil {
+			return err
+		}
+
+		(*tiles)[pos] = tile
+	}
+
+	return nil
+}
+
+func (tiles TileMap3D) IsEqualTo(otherTiles TileMap3D) bool {
+	if len(tiles) != len(otherTiles) {
+		return false
+	}
+
+	for key, value := range tiles {
+		if otherValue, ok := otherTiles[key]; !ok || !value.IsEqualTo(otherValue) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns the opposite corners of the smallest box containing all the tiles,
+// both are [0,0,0] if there are no tiles
+func (tiles TileMap3D) BoundingBox() (lowerCorner Vec3Di, upperCorner Vec3Di) {
+	first := true
+	for pos := range tiles {
+		if first {
+			lowerCorner, upperCorner = pos, pos
+			first = false
+			continue
+		}
+		for i := 0; i < 3; i += 1 {
+			if pos[i] < lowerCorner[i] {
+				lowerCorner[i] = pos[i]
+			}
+			if pos[i] > upperCorner[i] {
+				upperCorner[i] = pos[i]
+			}
+		}
+	}
+	return lowerCorner, upperCorner
+}
+
+// Returns the positions of the tiles ordered by z, then in the canonical order of each slice
+func (tiles TileMap3D) SortedPositions() []Vec3Di {
+	positions := make([]Vec3Di, 0, len(tiles))
+	for pos := range tiles {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		for k := 2; k >= 0; k -= 1 {
+			if positions[i][k] != positions[j][k] {
+				return positions[i][k] < positions[j][k]
+			}
+		}
+		return false
+	})
+	return positions
+}
+
+// Returns the tiles at height z, tiles keep their up and down glues
+func (tiles TileMap3D) Slice(z int) TileMap {
+	slice := make(TileMap)
+	for pos, tile := range tiles {
+		if pos[2] == z {
+			slice[Vec2Di{pos[0], pos[1]}] = tile
+		}
+	}
+	return slice
+}
+
+// Returns the heights of the slices holding at least one tile, in increasing order
+func (tiles TileMap3D) Heights() []int {
+	seen := make(map[int]bool)
+	var heights []int
+	for pos := range tiles {
+		if !seen[pos[2]] {
+			seen[pos[2]] = true
+			heights = append(heights, pos[2])
+		}
+	}
+	sort.Ints(heights)
+	return heights
+}
@@ -0,0 +1,110 @@
+package tamtam
+
+import (
+	"fmt"
+	"io"
+)
+
+type TileDifference struct {
+	Pos       Vec2Di      `json:"pos"`
+	Tile      SquareGlues `json:"tile"`
+	OtherTile SquareGlues `json:"other_tile"`
+}
+
+// Differences between two tile maps, the other tile map being translated by Offset.
+// Positions are the ones of the first tile map, in the canonical order (see TileMap.SortedPositions).
+type TileMapDiff struct {
+	Offset       Vec2Di           `json:"offset"`
+	OnlyInFirst  []PosAndTile     `json:"only_in_first"`
+	OnlyInSecond []PosAndTile     `json:"only_in_second"`
+	Different    []TileDifference `json:"different"`
+}
+
+func (tiles TileMap) Diff(otherTiles TileMap) TileMapDiff {
+	return tiles.DiffWithOffset(otherTiles, Vec2Di{0, 0})
+}
+
+// Compares the tiles with the other tiles translated by the offset
+func (tiles TileMap) DiffWithOffset(otherTiles TileMap, offset Vec2Di) (diff TileMapDiff) {
+	diff.Offset = offset
+	diff.OnlyInFirst = []PosAndTile{}
+	diff.OnlyInSecond = []PosAndTile{}
+	diff.Different = []TileDifference{}
+
+	translated := make(TileMap)
+	for pos, tile := range otherTiles {
+		translated[pos.Add(offset)] = tile
+	}
+
+	for _, pos := range tiles.SortedPositions() {
+		tile := tiles[pos]
+		if otherTile, ok := translated[pos]; !ok {
+			diff.OnlyInFirst = append(diff.OnlyInFirst, PosAndTile{Pos: pos, Tile: tile})
+		} else if !tile.IsEqualTo(otherTile) {
+			diff.Different = append(diff.Different, TileDifference{Pos: pos, Tile: tile, OtherTile: otherTile})
+		}
+	}
+
+	for _, pos := range translated.SortedPositions() {
+		if _, ok := tiles[pos]; !ok {
+			diff.OnlyInSecond = append(diff.OnlyInSecond, PosAndTile{Pos: pos, Tile: translated[pos]})
+		}
+	}
+
+	return diff
+}
+
+// Compares the tiles with the other tiles translated so that the lower left
+// corners of their bounding boxes coincide
+func (tiles TileMap) AlignedDiff(otherTiles TileMap) TileMapDiff {
+	lowerLeft, _ := tiles.BoundingBox()
+	otherLowerLeft, _ := otherTiles.BoundingBox()
+	return tiles.DiffWithOffset(otherTiles, Vec2Di{lowerLeft[0] - otherLowerLeft[0], lowerLeft[1] - otherLowerLeft[1]})
+}
+
+// Number of positions where the tile maps differ
+func (diff TileMapDiff) Count() int {
+	return len(diff.OnlyInFirst) + len(diff.OnlyInSecond) + len(diff.Different)
+}
+
+func (diff TileMapDiff) IsEmpty() bool {
+	return diff.Count() == 0
+}
+
+// Prints one line per difference: "-" for tiles only in the first tile map,
+// "+" for tiles only in the second one and "~" for differing tiles
+func (diff TileMapDiff) WriteText(w io.Writer, tileSet TileSet) error {
+	tileNames := tileSet.TileNames()
+	describe := func(tile SquareGlues) string {
+		if name, ok := tileNames[tile]; ok {
+			return name
+		}
+		return unnamedTileKey(tile)
+	}
+
+	if diff.Offset != (Vec2Di{0, 0}) {
+		if _, err := fmt.Fprintln(w, "offset", diff.Offset); err != nil {
+			return err
+		}
+	}
+
+	for _, posAndTile := range diff.OnlyInFirst {
+		if _, err := fmt.Fprintln(w, "-", posAndTile.Pos, describe(posAndTile.Tile)); err != nil {
+			return err
+		}
+	}
+
+	for _, posAndTile := range diff.OnlyInSecond {
+		if _, err := fmt.Fprintln(w, "+", posAndTile.Pos, describe(posAndTile.Tile)); err != nil {
+			return err
+		}
+	}
+
+	for _, difference := range diff.Different {
+		if _, err := fmt.Fprintln(w, "~", difference.Pos, describe(difference.Tile), "->", describe(difference.OtherTile)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
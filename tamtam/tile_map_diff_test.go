@@ -0,0 +1,49 @@
+package tamtam
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTileMapDiff(t *testing.T) {
+	tiles := grownCrtAssembly(t, 6).GetTileMap()
+
+	translated := make(TileMap)
+	for pos, tile := range tiles {
+		translated[pos.Add(Vec2Di{3, -2})] = tile
+	}
+
+	if diff := tiles.Diff(translated); diff.IsEmpty() {
+		t.Fatalf(`Translated tiles should differ without alignment`)
+	}
+
+	if diff := tiles.AlignedDiff(translated); !diff.IsEmpty() || diff.Offset != (Vec2Di{-3, 2}) {
+		t.Fatalf(`Aligned diff %+v should be empty with offset [-3 2]`, diff)
+	}
+
+	other := make(TileMap)
+	for pos, tile := range tiles {
+		other[pos] = tile
+	}
+	delete(other, Vec2Di{0, 0})
+	other[Vec2Di{1, 1}] = SquareGlues{"x", "x", "x", "x"}
+	other[Vec2Di{10, 10}] = SquareGlues{"x", "x", "x", "x"}
+
+	diff := tiles.Diff(other)
+
+	if len(diff.OnlyInFirst) != 1 || len(diff.OnlyInSecond) != 1 || len(diff.Different) != 1 || diff.Different[0].Pos != (Vec2Di{1, 1}) {
+		t.Fatalf(`Unexpected diff %+v`, diff)
+	}
+
+	var text bytes.Buffer
+
+	if err := diff.WriteText(&text, TileSet{}); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	expected := "- [0 0] [0 1 1 0]\n+ [10 10] [x x x x]\n~ [1 1] [0 0 0 0] -> [x x x x]\n"
+
+	if text.String() != expected {
+		t.Fatalf(`Diff text %q instead of %q`, text.String(), expected)
+	}
+}
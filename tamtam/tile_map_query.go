@@ -0,0 +1,189 @@
+package tamtam
+
+// Queries on tile maps. Results are given in the canonical order of positions:
+// rows from south to north (increasing y), each row from west to east (increasing x).
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Wildcards of tile patterns
+const PATTERN_ANY_TILE = "*"
+const PATTERN_EMPTY = "."
+const PATTERN_ANYTHING = "?"
+
+func sortCanonically(positions []Vec2Di) {
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i][1] != positions[j][1] {
+			return positions[i][1] < positions[j][1]
+		}
+		return positions[i][0] < positions[j][0]
+	})
+}
+
+// Returns the positions of the tiles in the canonical order
+func (tiles TileMap) SortedPositions() []Vec2Di {
+	positions := make([]Vec2Di, 0, len(tiles))
+	for pos := range tiles {
+		positions = append(positions, pos)
+	}
+	sortCanonically(positions)
+	return positions
+}
+
+// Returns the tiles whose position satisfies the predicate, in the canonical order
+func (tiles TileMap) selectTiles(predicate func(pos Vec2Di, tile SquareGlues) bool) []PosAndTile {
+	var positions []Vec2Di
+	for pos, tile := range tiles {
+		if predicate(pos, tile) {
+			positions = append(positions, pos)
+		}
+	}
+	sortCanonically(positions)
+
+	selected := make([]PosAndTile, len(positions))
+	for i, pos := range positions {
+		selected[i] = PosAndTile{Pos: pos, Tile: tiles[pos]}
+	}
+	return selected
+}
+
+// Returns the tiles of the row, from west to east
+func (tiles TileMap) Row(y int) []PosAndTile {
+	return tiles.selectTiles(func(pos Vec2Di, tile SquareGlues) bool { return pos[1] == y })
+}
+
+// Returns the tiles of the column, from south to north
+func (tiles TileMap) Column(x int) []PosAndTile {
+	return tiles.selectTiles(func(pos Vec2Di, tile SquareGlues) bool { return pos[0] == x })
+}
+
+// Returns the tiles in the rectangle delimited by the lower left and upper right corners (both included)
+func (tiles TileMap) Window(lowerLeft Vec2Di, upperRight Vec2Di) []PosAndTile {
+	return tiles.selectTiles(func(pos Vec2Di, tile SquareGlues) bool {
+		return pos[0] >= lowerLeft[0] && pos[0] <= upperRight[0] && pos[1] >= lowerLeft[1] && pos[1] <= upperRight[1]
+	})
+}
+
+func (tiles TileMap) PositionsOfTile(tile SquareGlues) []Vec2Di {
+	var positions []Vec2Di
+	for _, posAndTile := range tiles.selectTiles(func(pos Vec2Di, other SquareGlues) bool { return other == tile }) {
+		positions = append(positions, posAndTile.Pos)
+	}
+	return positions
+}
+
+// Returns the name of each tile, "" for tiles which are not in the tile set
+func (tileSet TileSet) NamesOf(tiles []PosAndTile) []string {
+	tileNames := tileSet.TileNames()
+	names := make([]string, len(tiles))
+	for i, posAndTile := range tiles {
+		names[i] = tileNames[posAndTile.Tile]
+	}
+	return names
+}
+
+// Rectangle of tile names given row by row from north to south, as the pattern
+// would be seen on screen. Besides tile names, cells can be PATTERN_ANY_TILE
+// (any tile), PATTERN_EMPTY (no tile) or PATTERN_ANYTHING (tile or no tile).
+type TilePattern [][]string
+
+// Parses a pattern given as lines of space separated cells, empty lines are ignored
+func ParseTilePattern(text string) (pattern TilePattern) {
+	for _, line := range strings.Split(text, "\n") {
+		if cells := strings.Fields(line); len(cells) > 0 {
+			pattern = append(pattern, cells)
+		}
+	}
+	return pattern
+}
+
+// Returns the lower left corners of the occurrences of the pattern, in the canonical order.
+// Patterns must be rectangular, only use names of the tile set and contain at least one tile.
+func (tiles TileMap) FindPattern(pattern TilePattern, tileSet TileSet) ([]Vec2Di, error) {
+	height := len(pattern)
+
+	if height == 0 || len(pattern[0]) == 0 {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	width := len(pattern[0])
+
+	// Offsets of the cells from the lower left corner, the anchor being a cell that must hold a tile
+	type cell struct {
+		offset Vec2Di
+		value  string
+	}
+	var cells []cell
+	anchor := -1
+
+	for row, rowCells := range pattern {
+		if len(rowCells) != width {
+			return nil, fmt.Errorf("pattern row %d has %d cells instead of %d", row+1, len(rowCells), width)
+		}
+
+		for column, value := range rowCells {
+			switch value {
+			case PATTERN_ANYTHING:
+				continue
+			case PATTERN_EMPTY:
+			case PATTERN_ANY_TILE:
+				if anchor < 0 {
+					anchor = len(cells)
+				}
+			default:
+				if _, ok := tileSet[value]; !ok {
+					return nil, fmt.Errorf("tile %q of the pattern is not in the tile set", value)
+				}
+				// Tile names are the most selective anchors
+				if anchor < 0 || cells[anchor].value == PATTERN_ANY_TILE {
+					anchor = len(cells)
+				}
+			}
+			cells = append(cells, cell{offset: Vec2Di{column, height - 1 - row}, value: value})
+		}
+	}
+
+	if anchor < 0 {
+		return nil, fmt.Errorf("the pattern must contain at least one tile")
+	}
+
+	matchesCell := func(pos Vec2Di, value string) bool {
+		tile, ok := tiles[pos]
+		switch value {
+		case PATTERN_EMPTY:
+			return !ok
+		case PATTERN_ANY_TILE:
+			return ok
+		}
+		return ok && tile == tileSet[value]
+	}
+
+	var corners []Vec2Di
+
+	for pos := range tiles {
+		if !matchesCell(pos, cells[anchor].value) {
+			continue
+		}
+
+		corner := Vec2Di{pos[0] - cells[anchor].offset[0], pos[1] - cells[anchor].offset[1]}
+		matches := true
+
+		for _, cell := range cells {
+			if !matchesCell(corner.Add(cell.offset), cell.value) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			corners = append(corners, corner)
+		}
+	}
+
+	sortCanonically(corners)
+
+	return corners, nil
+}
@@ -0,0 +1,77 @@
+package tamtam
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTileMapQueries(t *testing.T) {
+	SIZE := 8
+	assembly := grownCrtAssembly(t, SIZE)
+	tiles := assembly.GetTileMap()
+
+	row := tiles.Row(2)
+
+	if len(row) != SIZE+1 || row[0].Pos != (Vec2Di{-1, 2}) || row[SIZE].Pos != (Vec2Di{SIZE - 1, 2}) {
+		t.Fatalf(`Unexpected row %v`, row)
+	}
+
+	if column := tiles.Column(-1); len(column) != SIZE || column[0].Pos != (Vec2Di{-1, 0}) {
+		t.Fatalf(`Unexpected column %v`, column)
+	}
+
+	window := tiles.Window(Vec2Di{0, 0}, Vec2Di{2, 1})
+
+	if len(window) != 6 || window[2].Pos != (Vec2Di{2, 0}) || window[3].Pos != (Vec2Di{0, 1}) {
+		t.Fatalf(`Unexpected window %v`, window)
+	}
+
+	names := assembly.TileSet.NamesOf(tiles.Row(0))
+
+	if names[0] != "" || strings.Join(names[1:], " ") == "" {
+		t.Fatalf(`Unexpected names %v`, names)
+	}
+
+	// Looking for the 2x2 square at [3, 4] by the names of its tiles
+	tileNames := assembly.TileSet.TileNames()
+	pattern := TilePattern{
+		{tileNames[tiles[Vec2Di{3, 5}]], tileNames[tiles[Vec2Di{4, 5}]]},
+		{tileNames[tiles[Vec2Di{3, 4}]], tileNames[tiles[Vec2Di{4, 4}]]},
+	}
+
+	corners, err := tiles.FindPattern(pattern, assembly.TileSet)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	found := false
+	for _, corner := range corners {
+		found = found || corner == Vec2Di{3, 4}
+	}
+
+	if !found {
+		t.Fatalf(`Pattern %v not found at [3 4], found at %v`, pattern, corners)
+	}
+
+	// A single tile pattern gives the positions of the tile
+	single := TilePattern{{tileNames[tiles[Vec2Di{0, 0}]]}}
+
+	if corners, err := tiles.FindPattern(single, assembly.TileSet); err != nil || !reflect.DeepEqual(corners, tiles.PositionsOfTile(tiles[Vec2Di{0, 0}])) {
+		t.Fatalf(`Single tile pattern gave %v, %v`, corners, err)
+	}
+
+	// Upper right corner: a tile with no tile east of it nor north of it
+	corners, err = tiles.FindPattern(ParseTilePattern(". .\n* ."), assembly.TileSet)
+
+	if err != nil || !reflect.DeepEqual(corners, []Vec2Di{{SIZE - 1, SIZE - 1}}) {
+		t.Fatalf(`Corner pattern gave %v, %v`, corners, err)
+	}
+
+	for _, invalid := range []string{"? .", "* *\n*", "unknown"} {
+		if _, err := tiles.FindPattern(ParseTilePattern(invalid), assembly.TileSet); err == nil {
+			t.Fatalf(`Expected an error for pattern %q`, invalid)
+		}
+	}
+}
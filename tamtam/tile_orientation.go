@@ -0,0 +1,146 @@
+package tamtam
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Orientations in which the tile types of an assembly can be placed
+type Orientations string
+
+const (
+	// Tile types are placed as they are defined, the default
+	ORIENTATIONS_FIXED Orientations = ""
+	// Tile types can also be rotated by 90, 180 and 270 degrees
+	ORIENTATIONS_ROTATIONS Orientations = "rotations"
+	// Tile types can also be rotated and reflected
+	ORIENTATIONS_ROTATIONS_AND_REFLECTIONS Orientations = "rotations_and_reflections"
+)
+
+// Separates the name of a tile type from its orientation in the names of oriented tiles,
+// such as "a#r90" (rotated clockwise by 90 degrees) or "a#m270" (reflected east to west,
+// then rotated by 270 degrees)
+const ORIENTATION_SEPARATOR = "#"
+
+func (orientations Orientations) Validate() error {
+	switch orientations {
+	case ORIENTATIONS_FIXED, ORIENTATIONS_ROTATIONS, ORIENTATIONS_ROTATIONS_AND_REFLECTIONS:
+		return nil
+	}
+	return errors.New("unknown orientations " + string(orientations))
+}
+
+// Returns an error if tiles can be placed in other orientations and a tile name holds
+// ORIENTATION_SEPARATOR, as it could then be the name of another oriented tile
+func (orientations Orientations) checkTileNames(tileSet TileSet) error {
+	if orientations == ORIENTATIONS_FIXED {
+		return nil
+	}
+
+	for _, name := range tileSet.SortedNames() {
+		if strings.Contains(name, ORIENTATION_SEPARATOR) {
+			return errors.New("tile names cannot hold " + ORIENTATION_SEPARATOR + " when tiles are not in fixed orientations: " + name)
+		}
+	}
+
+	return nil
+}
+
+// Returns the square tile rotated clockwise by the given number of quarter turns
+func (glues Glues) Rotated(quarterTurns int) (rotated Glues) {
+	for side := 0; side < 4; side += 1 {
+		rotated[(side+quarterTurns)%4] = glues[side]
+	}
+	return rotated
+}
+
+// Returns the square tile reflected across its north-south axis, east and west are swapped
+func (glues Glues) Reflected() Glues {
+	return Glues{glues[0], glues[3], glues[2], glues[1]}
+}
+
+// Returns the glues of the tile reflected if asked to, then rotated
+func (glues Glues) Oriented(quarterTurns int, reflected bool) Glues {
+	if reflected {
+		glues = glues.Reflected()
+	}
+	return glues.Rotated(quarterTurns)
+}
+
+// Name of the tile type in the orientation, the name itself for the original orientation
+func OrientedName(name string, quarterTurns int, reflected bool) string {
+	orientation := ""
+	if reflected {
+		orientation = "m"
+	}
+	if quarterTurns != 0 {
+		if !reflected {
+			orientation = "r"
+		}
+		orientation += strconv.Itoa(quarterTurns * 90)
+	}
+	if orientation == "" {
+		return name
+	}
+	return name + ORIENTATION_SEPARATOR + orientation
+}
+
+// Splits the name of an oriented tile, see OrientedName. Names without
+// orientation are in the original orientation.
+func ParseOrientedName(orientedName string) (name string, quarterTurns int, reflected bool) {
+	separator := strings.LastIndex(orientedName, ORIENTATION_SEPARATOR)
+
+	if separator < 0 {
+		return orientedName, 0, false
+	}
+
+	orientation := orientedName[separator+len(ORIENTATION_SEPARATOR):]
+	reflected = strings.HasPrefix(orientation, "m")
+
+	if !reflected && (!strings.HasPrefix(orientation, "r") || orientation == "r") {
+		return orientedName, 0, false
+	}
+
+	if degrees := orientation[1:]; degrees != "" {
+		value, err := strconv.Atoi(degrees)
+		if err != nil || value <= 0 || value >= 360 || value%90 != 0 {
+			return orientedName, 0, false
+		}
+		quarterTurns = value / 90
+	}
+
+	return orientedName[:separator], quarterTurns, reflected
+}
+
+// Returns the tile set holding every orientation of the tile types, named as with OrientedName.
+// Orientations giving the same glues as an orientation listed before are left out.
+func (tileSet TileSet) WithOrientations(orientations Orientations) TileSet {
+	if orientations == ORIENTATIONS_FIXED {
+		return tileSet
+	}
+
+	reflections := []bool{false}
+	if orientations == ORIENTATIONS_ROTATIONS_AND_REFLECTIONS {
+		reflections = append(reflections, true)
+	}
+
+	oriented := make(TileSet)
+
+	for _, name := range tileSet.SortedNames() {
+		seen := make(map[Glues]bool)
+
+		for _, reflected := range reflections {
+			for quarterTurns := 0; quarterTurns < 4; quarterTurns += 1 {
+				tile := tileSet[name].Oriented(quarterTurns, reflected)
+
+				if !seen[tile] {
+					seen[tile] = true
+					oriented[OrientedName(name, quarterTurns, reflected)] = tile
+				}
+			}
+		}
+	}
+
+	return oriented
+}
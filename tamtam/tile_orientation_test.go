@@ -0,0 +1,119 @@
+package tamtam
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOrientedNames(t *testing.T) {
+	for _, name := range []string{"a", "a#r90", "a#r180", "a#r270", "a#m", "a#m90", "a#m270", "a#b#r90"} {
+		base, quarterTurns, reflected := ParseOrientedName(name)
+		if OrientedName(base, quarterTurns, reflected) != name {
+			t.Fatalf(`%q parsed as %q %d %v`, name, base, quarterTurns, reflected)
+		}
+	}
+
+	for _, name := range []string{"a#", "a#r", "a#r45", "a#r360", "a#x90"} {
+		if base, _, _ := ParseOrientedName(name); base != name {
+			t.Fatalf(`%q parsed as an oriented name of %q`, name, base)
+		}
+	}
+}
+
+func TestWithOrientations(t *testing.T) {
+	tile := SquareGlues{"n", "e", NULL_GLUE, NULL_GLUE}
+
+	if rotated := tile.Rotated(1); rotated != (SquareGlues{NULL_GLUE, "n", "e", NULL_GLUE}) {
+		t.Fatalf(`Unexpected rotation %v`, rotated)
+	}
+
+	if reflected := tile.Reflected(); reflected != (SquareGlues{"n", NULL_GLUE, NULL_GLUE, "e"}) {
+		t.Fatalf(`Unexpected reflection %v`, reflected)
+	}
+
+	tileSet := TileSet{"corner": tile, "cross": SquareGlues{"x", "x", "x", "x"}, "bar": SquareGlues{"x", NULL_GLUE, "x", NULL_GLUE}}
+
+	rotations := tileSet.WithOrientations(ORIENTATIONS_ROTATIONS)
+	if len(rotations) != 4+1+2 {
+		t.Fatalf(`%d oriented tiles with rotations: %v`, len(rotations), rotations.SortedNames())
+	}
+
+	all := tileSet.WithOrientations(ORIENTATIONS_ROTATIONS_AND_REFLECTIONS)
+	if len(all) != 8+1+2 || all["corner#m90"] != tile.Reflected().Rotated(1) {
+		t.Fatalf(`%d oriented tiles with rotations and reflections: %v`, len(all), all.SortedNames())
+	}
+}
+
+// The seed only binds with the west side of a tile whose glue is on its north side
+func TestRotatedGrowth(t *testing.T) {
+	tileSet := TileSet{"a": SquareGlues{"x", NULL_GLUE, NULL_GLUE, NULL_GLUE}}
+	seed := TileMap{{0, 0}: SquareGlues{NULL_GLUE, "x", NULL_GLUE, NULL_GLUE}}
+
+	assembly := NewAssembly(tileSet, seed, 1)
+
+	if didGrow, _ := assembly.GrowSync(true); didGrow {
+		t.Fatalf(`Fixed tile grew`)
+	}
+
+	if err := assembly.SetOrientations(ORIENTATIONS_ROTATIONS); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if didGrow, err := assembly.GrowSync(true); !didGrow || err != nil {
+		t.Fatalf(`Rotated tile did not grow: %v`, err)
+	}
+
+	if name := assembly.GetOrientedTileSet().TileNames()[assembly.GetTileMap()[Vec2Di{1, 0}]]; name != "a#r270" {
+		t.Fatalf(`Placed tile %q instead of "a#r270"`, name)
+	}
+
+	if stats := assembly.Statistics(); stats.TileCounts["a#r270"] != 1 {
+		t.Fatalf(`Unexpected tile counts %v`, stats.TileCounts)
+	}
+
+	b, err := json.Marshal(assembly)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	var decoded TileAssembly
+
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !decoded.IsEqualTo(assembly) || decoded.GetOrientations() != ORIENTATIONS_ROTATIONS {
+		t.Fatalf(`Decoded assembly differs from the original one`)
+	}
+
+	if err := decoded.SetLattice(LATTICE_HEXAGONAL); err == nil {
+		t.Fatalf(`Rotatable tiles accepted on the hexagonal lattice`)
+	}
+}
+
+// "a#r90" would be both a tile type and the rotation of "a"
+func TestOrientationSeparatorInTileNames(t *testing.T) {
+	tile := SquareGlues{"n", "e", NULL_GLUE, NULL_GLUE}
+	system := TileSystem{
+		TileSet:      TileSet{"a": tile, "a#r90": tile.Rotated(2)},
+		InitialTiles: TileMap{Vec2Di{0, 0}: tile},
+		Threshold:    1,
+	}
+
+	if err := system.Validate(); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	system.Orientations = ORIENTATIONS_ROTATIONS
+
+	if err := system.Validate(); err == nil {
+		t.Fatalf(`Tile name holding the orientation separator accepted`)
+	}
+
+	assembly := NewAssembly(system.TileSet, system.InitialTiles, 1)
+
+	if err := assembly.SetOrientations(ORIENTATIONS_ROTATIONS); err == nil || assembly.GetOrientations() != ORIENTATIONS_FIXED {
+		t.Fatalf(`Orientations set with a tile name holding the orientation separator`)
+	}
+}
@@ -2,6 +2,8 @@ package tamtam
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"strconv"
 
 	primes "github.com/fxtlabs/primes"
@@ -9,10 +11,13 @@ import (
 
 const NULL_GLUE string = ""
 
-// North, East, South, West
-type SquareGlues [4]string
+// Glues of a tile, in the order of the sides of its lattice (see Lattice)
+type Glues [MAX_SIDES]string
 
-func (glues SquareGlues) IsEqualTo(otherGlues SquareGlues) bool {
+// Glues of a square tile: north, east, south, west
+type SquareGlues = Glues
+
+func (glues Glues) IsEqualTo(otherGlues Glues) bool {
 	for i, val := range glues {
 		if otherGlues[i] != val {
 			return false
@@ -23,6 +28,34 @@ func (glues SquareGlues) IsEqualTo(otherGlues SquareGlues) bool {
 
 type TileSet map[string]SquareGlues
 
+// Strength of each glue, glues that are not listed have strength 1. Glues of negative
+// strength repel: when they match they lower the binding strength of tiles.
+type GlueStrengths map[string]int
+
+func (strengths GlueStrengths) Strength(glue string) int {
+	if glue == NULL_GLUE {
+		return 0
+	}
+	if strength, ok := strengths[glue]; ok {
+		return strength
+	}
+	return 1
+}
+
+func (strengths GlueStrengths) IsEqualTo(otherStrengths GlueStrengths) bool {
+	for glue, strength := range strengths {
+		if otherStrengths.Strength(glue) != strength {
+			return false
+		}
+	}
+	for glue, strength := range otherStrengths {
+		if strengths.Strength(glue) != strength {
+			return false
+		}
+	}
+	return true
+}
+
 func (tileSet TileSet) IsEqualTo(otherTileSet TileSet) bool {
 
 	if len(tileSet) != len(otherTileSet) {
@@ -38,18 +71,21 @@ func (tileSet TileSet) IsEqualTo(otherTileSet TileSet) bool {
 }
 
 func (tileSet TileSet) MatchTiles(glueConstraints SquareGlues, threshold int) (matches []SquareGlues) {
+	return tileSet.MatchTilesWithStrengths(glueConstraints, nil, threshold)
+}
+
+// Returns the tile types whose matching glues with the constraints have a total strength of at least threshold,
+// matching glues of negative strength lowering the total. Every side counts: a side whose glue does
+// not match adds nothing but does not prevent the other sides from binding.
+func (tileSet TileSet) MatchTilesWithStrengths(glueConstraints SquareGlues, strengths GlueStrengths, threshold int) (matches []SquareGlues) {
 
 	for _, tileType := range tileSet {
 		var count = 0
-		for i := 0; i < 4; i += 1 {
-			if glueConstraints[i] == NULL_GLUE {
+		for i := 0; i < MAX_SIDES; i += 1 {
+			if glueConstraints[i] == NULL_GLUE || glueConstraints[i] != tileType[i] {
 				continue
 			}
-
-			if glueConstraints[i] != tileType[i] {
-				break
-			}
-			count += 1
+			count += strengths.Strength(glueConstraints[i])
 		}
 		if count >= threshold {
 			matches = append(matches, tileType)
@@ -59,17 +95,61 @@ func (tileSet TileSet) MatchTiles(glueConstraints SquareGlues, threshold int) (m
 	return matches
 }
 
-// Creates a Chinese Remainder Tile Set
+// Returns the name of each tile type, when several names share the same glues
+// the smallest one is kept so that the result does not depend on map ordering
+func (tileSet TileSet) TileNames() map[SquareGlues]string {
+	names := make(map[SquareGlues]string)
+	for name, tileType := range tileSet {
+		if otherName, ok := names[tileType]; !ok || name < otherName {
+			names[tileType] = name
+		}
+	}
+	return names
+}
+
+// Returns the tile names in a stable order, see sortNames
+func (tileSet TileSet) SortedNames() []string {
+	names := make([]string, 0, len(tileSet))
+	for name := range tileSet {
+		names = append(names, name)
+	}
+
+	sortNames(names)
+
+	return names
+}
+
+// Sorts names with integer names sorted numerically so that "2" comes before "10" and before non integer names
+func sortNames(names []string) {
+	sort.Slice(names, func(i, j int) bool {
+		a, errA := strconv.Atoi(names[i])
+		b, errB := strconv.Atoi(names[j])
+		if errA == nil && errB == nil && a != b {
+			return a < b
+		}
+		if (errA == nil) != (errB == nil) {
+			return errA == nil
+		}
+		return names[i] < names[j]
+	})
+}
+
+// Creates a Chinese Remainder Tile Set: tile i reads i / p on its west side and i % p on
+// its south side, and writes i / q on its north side and i % q on its east side
 func NewCrtTileSet(p int, q int) (tileSet TileSet, err error) {
 
 	tileSet = make(TileSet)
 
+	if p <= 0 || q <= 0 {
+		return tileSet, errors.New("p and q must be positive")
+	}
+
 	if !primes.Coprime(p, q) {
 		return tileSet, errors.New("p and q must be co-primes")
 	}
 
 	for i := 0; i < p*q; i += 1 {
-		tileSet[strconv.Itoa(i)] = SquareGlues{strconv.FormatInt(int64(i)/3, 10), strconv.FormatInt(int64(i)%3, 10), strconv.FormatInt(int64(i)%2, 10), strconv.FormatInt(int64(i)/2, 10)}
+		tileSet[strconv.Itoa(i)] = SquareGlues{strconv.Itoa(i / q), strconv.Itoa(i % q), strconv.Itoa(i % p), strconv.Itoa(i / p)}
 	}
 
 	return tileSet, nil
@@ -86,3 +166,68 @@ func (tileSet TileSet) GetTileName(tile SquareGlues) (tileName string, err error
 
 	return "", errors.New("The tile type is not in the tile set")
 }
+
+// Checks the tile set for errors (unnamed tiles, tiles without glues, tile types
+// defined twice) and returns warnings about glues that can never bind because
+// no tile has them on the opposite side
+func (tileSet TileSet) Validate() (errs []error, warnings []string) {
+	return tileSet.ValidateOnLattice(LATTICE_SQUARE)
+}
+
+// Validates the tile set for tiles of the lattice, tiles having glues on sides that
+// the lattice does not have are errors
+func (tileSet TileSet) ValidateOnLattice(lattice Lattice) (errs []error, warnings []string) {
+	names := make([]string, 0, len(tileSet))
+	for name := range tileSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// For each side, the glues found on that side
+	var gluesOnSide [MAX_SIDES]map[string]bool
+	for side := 0; side < MAX_SIDES; side += 1 {
+		gluesOnSide[side] = make(map[string]bool)
+	}
+
+	firstName := make(map[SquareGlues]string)
+
+	for _, name := range names {
+		tile := tileSet[name]
+
+		if name == "" {
+			errs = append(errs, errors.New("a tile has an empty name"))
+		}
+
+		if tile.IsEqualTo(Glues{}) {
+			errs = append(errs, fmt.Errorf("tile %q has no glue", name))
+		}
+
+		if !lattice.fits(tile) {
+			errs = append(errs, fmt.Errorf("tile %q has glues on sides that %s tiles do not have", name, lattice))
+		}
+
+		if otherName, ok := firstName[tile]; ok {
+			errs = append(errs, fmt.Errorf("tiles %q and %q have the same glues", otherName, name))
+		} else {
+			firstName[tile] = name
+		}
+
+		for side, glue := range tile {
+			if glue != NULL_GLUE {
+				gluesOnSide[side][glue] = true
+			}
+		}
+	}
+
+	sideNames := lattice.SideNames()
+	for _, name := range names {
+		for side := 0; side < lattice.Sides(); side += 1 {
+			glue, opposite := tileSet[name][side], lattice.Opposite(side)
+			if glue != NULL_GLUE && !gluesOnSide[opposite][glue] {
+				warnings = append(warnings, fmt.Sprintf("glue %q on the %s side of tile %q does not appear on the %s side of any tile", glue, sideNames[side], name, sideNames[opposite]))
+			}
+		}
+	}
+
+	return errs, warnings
+}
@@ -0,0 +1,48 @@
+package tamtam
+
+import "testing"
+
+// A mismatching side does not prevent the other sides from binding, whatever their order.
+// Matching used to stop at the first mismatching side, so that the cases below where the north
+// side mismatches gave no match: this was wrong in the abstract tile assembly model, where a
+// mismatch only adds no strength, and it hid the repulsions of later sides from negative glues.
+func TestMatchTiles(t *testing.T) {
+	tileSet := TileSet{"a": {"n", "e", "s", "w"}, "b": {"n", "x", "x", "x"}}
+
+	for _, test := range []struct {
+		constraints SquareGlues
+		threshold   int
+		matches     int
+	}{
+		{SquareGlues{"n", "e", "", ""}, 2, 1},
+		// The mismatching north side comes first
+		{SquareGlues{"x", "e", "s", ""}, 2, 1},
+		{SquareGlues{"x", "e", "", "w"}, 2, 1},
+		{SquareGlues{"x", "e", "", ""}, 2, 0},
+		{SquareGlues{"n", "", "", ""}, 1, 2},
+		{SquareGlues{"n", "x", "s", ""}, 2, 2},
+	} {
+		if matches := tileSet.MatchTiles(test.constraints, test.threshold); len(matches) != test.matches {
+			t.Fatalf(`%v at threshold %d: %d matches instead of %d`, test.constraints, test.threshold, len(matches), test.matches)
+		}
+	}
+}
+
+func TestCrtTileSet(t *testing.T) {
+	tileSet, err := NewCrtTileSet(3, 5)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	// 7 = 2 * 3 + 1 = 1 * 5 + 2
+	if len(tileSet) != 15 || tileSet["7"] != (SquareGlues{"1", "2", "1", "2"}) {
+		t.Fatalf(`Unexpected tile set %v`, tileSet)
+	}
+
+	for _, pq := range [][2]int{{2, 4}, {0, 3}} {
+		if _, err := NewCrtTileSet(pq[0], pq[1]); err == nil {
+			t.Fatalf(`Moduli %d and %d accepted`, pq[0], pq[1])
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package tamtam
+
+import "fmt"
+
+// Everything needed to start an assembly: tile types, glue strengths,
+// initial tiles (the seed), threshold (the temperature) and the growth model.
+// Its JSON encoding is the one of TileAssembly.
+type TileSystem struct {
+	TileSet       TileSet       `json:"tile_set"`
+	GlueStrengths GlueStrengths `json:"glue_strengths,omitempty"`
+	InitialTiles  TileMap       `json:"tile_map"`
+	Threshold     int           `json:"threshold"`
+	GrowthModel
+	// States of the signal tiles among the initial tiles
+	SignalStates []PosAndSignalState `json:"signal_states,omitempty"`
+}
+
+// Returns an error if the growth model is invalid (see Validate) or if signal states do not
+// match the initial tiles
+func (system TileSystem) NewAssembly() (TileAssembly, error) {
+	assembly := NewAssemblyWithGlueStrengths(system.TileSet, system.GlueStrengths, system.InitialTiles, system.Threshold)
+
+	if err := assembly.SetGrowthModel(system.GrowthModel); err != nil {
+		return assembly, err
+	}
+
+	return assembly, assembly.setSignalStates(system.SignalStates)
+}
+
+func (system TileSystem) IsEqualTo(otherSystem TileSystem) bool {
+	return system.Threshold == otherSystem.Threshold && system.GrowthModel.IsEqualTo(otherSystem.GrowthModel) && system.GlueStrengths.IsEqualTo(otherSystem.GlueStrengths) && system.TileSet.IsEqualTo(otherSystem.TileSet) && system.InitialTiles.IsEqualTo(otherSystem.InitialTiles)
+}
+
+// Checks that the lattice, the orientations and the geometry are known and fit together,
+// that the tiles fit the lattice and that concentrations are positive
+func (system TileSystem) Validate() error {
+	return system.GrowthModel.validate(system.TileSet, system.InitialTiles)
+}
+
+// Returns an error if the tile system is not on the square lattice or if the rest of its growth model,
+// concentrations aside, is not the default one, for formats that only hold square tiles in fixed
+// orientations growing on the plane
+func (system TileSystem) checkSquareLattice(format string) error {
+	if system.Lattice != LATTICE_SQUARE {
+		return fmt.Errorf("the %s format only holds square tiles, not %s ones", format, system.Lattice)
+	}
+	if err := system.checkDefaultModel(format); err != nil {
+		return err
+	}
+	return LATTICE_SQUARE.checkTiles(system.TileSet, system.InitialTiles)
+}
+
+// Returns an error if the tile system grows elsewhere than on the plane or has rotatable tiles,
+// signal tiles or detachment, for formats that only hold the lattice and concentrations of growth models
+func (system TileSystem) checkDefaultModel(format string) error {
+	if geometry := system.GetGeometry(); geometry.Kind != GEOMETRY_PLANE {
+		return fmt.Errorf("the %s format only holds systems growing on the plane, not in a %s geometry", format, geometry.Kind)
+	}
+	if system.Orientations != ORIENTATIONS_FIXED {
+		return fmt.Errorf("the %s format does not hold rotatable tiles", format)
+	}
+	if len(system.SignalTileSet) > 0 {
+		return fmt.Errorf("the %s format does not hold signal tiles", format)
+	}
+	if system.Detachment != DETACHMENT_NONE {
+		return fmt.Errorf("the %s format does not hold detachment", format)
+	}
+	return nil
+}
+
+// Returns an error if the tile system has tile concentrations, for formats that cannot hold them
+func (system TileSystem) checkNoConcentrations(format string) error {
+	if len(system.TileConcentrations) > 0 {
+		return fmt.Errorf("the %s format does not hold tile concentrations", format)
+	}
+	return nil
+}
@@ -0,0 +1,508 @@
+package tamtam
+
+// Compact binary encoding of tile systems, meant for very large assemblies.
+//
+// The header holds the magic "TAMB", the format version, the lattice (since version 2), the
+// threshold, the glue strengths and the tile set. It is followed by a stream of records, each
+// starting with a tag byte:
+//
+//	BINARY_TAG_TILE       x, y as signed varints relative to the previous tile, tile type index as uvarint
+//	BINARY_TAG_TILE_TYPE  the glues of a tile type which is not in the tile set (such as seed tiles)
+//	BINARY_TAG_END        end of the stream
+//
+// Tile types are indexed in the order of the tile set sorted by name, then in the order of their
+// BINARY_TAG_TILE_TYPE records. Tile types are written as one glue per side of the tiles of the
+// lattice. Strings are written as their uvarint length followed by their bytes and integers as
+// signed varints. Version 1 has no lattice, its tiles are square.
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const BINARY_MAGIC = "TAMB"
+const BINARY_VERSION = 2
+
+const (
+	BINARY_TAG_END byte = iota
+	BINARY_TAG_TILE
+	BINARY_TAG_TILE_TYPE
+)
+
+// Longest string accepted when decoding, protects against allocating memory for corrupted lengths
+const BINARY_MAX_STRING_LENGTH = 1 << 20
+
+type BinaryWriter struct {
+	w           *bufio.Writer
+	lattice     Lattice
+	tileIndices map[SquareGlues]int
+	numTypes    int
+	previous    Vec2Di
+	buffer      [binary.MaxVarintLen64]byte
+	err         error
+}
+
+// Writes the header of square tiles, tiles are then written one by one with WriteTile and
+// the stream must be terminated with Close (which does not close the underlying writer)
+func NewBinaryWriter(w io.Writer, tileSet TileSet, glueStrengths GlueStrengths, threshold int) (*BinaryWriter, error) {
+	return NewBinaryWriterOnLattice(w, LATTICE_SQUARE, tileSet, glueStrengths, threshold)
+}
+
+// Writes the header of tiles of the lattice, see NewBinaryWriter
+func NewBinaryWriterOnLattice(w io.Writer, lattice Lattice, tileSet TileSet, glueStrengths GlueStrengths, threshold int) (*BinaryWriter, error) {
+	if err := lattice.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := lattice.checkTiles(tileSet, nil); err != nil {
+		return nil, err
+	}
+
+	writer := &BinaryWriter{w: bufio.NewWriter(w), lattice: lattice, tileIndices: make(map[SquareGlues]int)}
+
+	writer.w.WriteString(BINARY_MAGIC)
+	writer.w.WriteByte(BINARY_VERSION)
+	writer.writeString(string(lattice))
+	writer.writeInt(threshold)
+
+	glues := make([]string, 0, len(glueStrengths))
+	for glue := range glueStrengths {
+		glues = append(glues, glue)
+	}
+	sort.Strings(glues)
+
+	writer.writeUint(uint64(len(glues)))
+	for _, glue := range glues {
+		writer.writeString(glue)
+		writer.writeInt(glueStrengths[glue])
+	}
+
+	names := tileSet.SortedNames()
+	writer.writeUint(uint64(len(names)))
+	writer.numTypes = len(names)
+	for i, name := range names {
+		writer.writeString(name)
+		writer.writeGlues(tileSet[name])
+
+		// Several names may share the same glues, the first one is used
+		if _, ok := writer.tileIndices[tileSet[name]]; !ok {
+			writer.tileIndices[tileSet[name]] = i
+		}
+	}
+
+	return writer, writer.err
+}
+
+func (writer *BinaryWriter) writeUint(value uint64) {
+	if writer.err == nil {
+		_, writer.err = writer.w.Write(writer.buffer[:binary.PutUvarint(writer.buffer[:], value)])
+	}
+}
+
+func (writer *BinaryWriter) writeInt(value int) {
+	if writer.err == nil {
+		_, writer.err = writer.w.Write(writer.buffer[:binary.PutVarint(writer.buffer[:], int64(value))])
+	}
+}
+
+func (writer *BinaryWriter) writeString(value string) {
+	writer.writeUint(uint64(len(value)))
+	if writer.err == nil {
+		_, writer.err = writer.w.WriteString(value)
+	}
+}
+
+func (writer *BinaryWriter) writeGlues(glues SquareGlues) {
+	for _, glue := range glues[:writer.lattice.Sides()] {
+		writer.writeString(glue)
+	}
+}
+
+func (writer *BinaryWriter) writeTag(tag byte) {
+	if writer.err == nil {
+		writer.err = writer.w.WriteByte(tag)
+	}
+}
+
+func (writer *BinaryWriter) WriteTile(pos Vec2Di, tile SquareGlues) error {
+	index, ok := writer.tileIndices[tile]
+
+	if !ok && !writer.lattice.fits(tile) {
+		return fmt.Errorf("the tile at %v has glues on sides that %s tiles do not have", pos, writer.lattice)
+	}
+
+	if !ok {
+		index = writer.numTypes
+		writer.numTypes += 1
+		writer.tileIndices[tile] = index
+		writer.writeTag(BINARY_TAG_TILE_TYPE)
+		writer.writeGlues(tile)
+	}
+
+	writer.writeTag(BINARY_TAG_TILE)
+	writer.writeInt(pos[0] - writer.previous[0])
+	writer.writeInt(pos[1] - writer.previous[1])
+	writer.writeUint(uint64(index))
+	writer.previous = pos
+
+	return writer.err
+}
+
+// Writes the end of the stream and flushes
+func (writer *BinaryWriter) Close() error {
+	writer.writeTag(BINARY_TAG_END)
+
+	if writer.err != nil {
+		return writer.err
+	}
+
+	return writer.w.Flush()
+}
+
+type BinaryReader struct {
+	Lattice       Lattice
+	TileSet       TileSet
+	GlueStrengths GlueStrengths
+	Threshold     int
+	r             *bufio.Reader
+	tileTypes     []SquareGlues
+	previous      Vec2Di
+	done          bool
+}
+
+func binaryFormatError(format string, a ...interface{}) error {
+	return fmt.Errorf("invalid binary tile system: %s", fmt.Sprintf(format, a...))
+}
+
+// Reads the header, the tiles are then read one by one with ReadTile
+func NewBinaryReader(r io.Reader) (*BinaryReader, error) {
+	reader := &BinaryReader{r: bufio.NewReader(r), TileSet: make(TileSet)}
+
+	magic := make([]byte, len(BINARY_MAGIC)+1)
+
+	if _, err := io.ReadFull(reader.r, magic); err != nil {
+		return nil, binaryFormatError("missing header")
+	}
+
+	if string(magic[:len(BINARY_MAGIC)]) != BINARY_MAGIC {
+		return nil, binaryFormatError("wrong magic number")
+	}
+
+	version := magic[len(BINARY_MAGIC)]
+
+	if version < 1 || version > BINARY_VERSION {
+		return nil, binaryFormatError("unsupported version %d", version)
+	}
+
+	var err error
+
+	if version >= 2 {
+		lattice, err := reader.readString()
+
+		if err != nil {
+			return nil, err
+		}
+
+		reader.Lattice = Lattice(lattice)
+
+		if err := reader.Lattice.Validate(); err != nil {
+			return nil, binaryFormatError("%v", err)
+		}
+	}
+
+	if reader.Threshold, err = reader.readInt(); err != nil {
+		return nil, err
+	}
+
+	numGlues, err := reader.readUint()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := uint64(0); i < numGlues; i += 1 {
+		glue, err := reader.readString()
+
+		if err != nil {
+			return nil, err
+		}
+
+		strength, err := reader.readInt()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if reader.GlueStrengths == nil {
+			reader.GlueStrengths = make(GlueStrengths)
+		}
+		reader.GlueStrengths[glue] = strength
+	}
+
+	numTiles, err := reader.readUint()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := uint64(0); i < numTiles; i += 1 {
+		name, err := reader.readString()
+
+		if err != nil {
+			return nil, err
+		}
+
+		tile, err := reader.readGlues()
+
+		if err != nil {
+			return nil, err
+		}
+
+		reader.TileSet[name] = tile
+		reader.tileTypes = append(reader.tileTypes, tile)
+	}
+
+	return reader, nil
+}
+
+func (reader *BinaryReader) readUint() (uint64, error) {
+	value, err := binary.ReadUvarint(reader.r)
+
+	if err != nil {
+		return 0, binaryFormatError("%v", err)
+	}
+
+	return value, nil
+}
+
+func (reader *BinaryReader) readInt() (int, error) {
+	value, err := binary.ReadVarint(reader.r)
+
+	if err != nil {
+		return 0, binaryFormatError("%v", err)
+	}
+
+	return int(value), nil
+}
+
+func (reader *BinaryReader) readString() (string, error) {
+	length, err := reader.readUint()
+
+	if err != nil {
+		return "", err
+	}
+
+	if length > BINARY_MAX_STRING_LENGTH {
+		return "", binaryFormatError("string of length %d", length)
+	}
+
+	b := make([]byte, length)
+
+	if _, err := io.ReadFull(reader.r, b); err != nil {
+		return "", binaryFormatError("%v", err)
+	}
+
+	return string(b), nil
+}
+
+func (reader *BinaryReader) readGlues() (glues SquareGlues, err error) {
+	for i := 0; i < reader.Lattice.Sides(); i += 1 {
+		if glues[i], err = reader.readString(); err != nil {
+			return glues, err
+		}
+	}
+	return glues, nil
+}
+
+// Returns the next tile, or io.EOF once the end of the stream is reached
+func (reader *BinaryReader) ReadTile() (pos Vec2Di, tile SquareGlues, err error) {
+	for !reader.done {
+		tag, err := reader.r.ReadByte()
+
+		if err != nil {
+			return pos, tile, binaryFormatError("missing end of stream")
+		}
+
+		switch tag {
+		case BINARY_TAG_END:
+			reader.done = true
+
+		case BINARY_TAG_TILE_TYPE:
+			tileType, err := reader.readGlues()
+
+			if err != nil {
+				return pos, tile, err
+			}
+
+			reader.tileTypes = append(reader.tileTypes, tileType)
+
+		case BINARY_TAG_TILE:
+			dx, err := reader.readInt()
+
+			if err != nil {
+				return pos, tile, err
+			}
+
+			dy, err := reader.readInt()
+
+			if err != nil {
+				return pos, tile, err
+			}
+
+			index, err := reader.readUint()
+
+			if err != nil {
+				return pos, tile, err
+			}
+
+			if index >= uint64(len(reader.tileTypes)) {
+				return pos, tile, binaryFormatError("unknown tile type %d", index)
+			}
+
+			reader.previous = Vec2Di{reader.previous[0] + dx, reader.previous[1] + dy}
+			return reader.previous, reader.tileTypes[index], nil
+
+		default:
+			return pos, tile, binaryFormatError("unknown record tag %d", tag)
+		}
+	}
+
+	return pos, tile, io.EOF
+}
+
+// Writes the tile set, the glue strengths, the threshold, the lattice and the tiles, the other
+// parts of the growth model cannot be encoded
+func WriteTileSystemBinary(w io.Writer, system TileSystem) error {
+	if err := system.checkDefaultModel("binary"); err != nil {
+		return err
+	}
+
+	if err := system.checkNoConcentrations("binary"); err != nil {
+		return err
+	}
+
+	writer, err := NewBinaryWriterOnLattice(w, system.Lattice, system.TileSet, system.GlueStrengths, system.Threshold)
+
+	if err != nil {
+		return err
+	}
+
+	for _, pos := range system.InitialTiles.SortedPositions() {
+		if err := writer.WriteTile(pos, system.InitialTiles[pos]); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+func ReadTileSystemBinary(r io.Reader) (system TileSystem, err error) {
+	reader, err := NewBinaryReader(r)
+
+	if err != nil {
+		return system, err
+	}
+
+	system.Lattice = reader.Lattice
+	system.TileSet = reader.TileSet
+	system.GlueStrengths = reader.GlueStrengths
+	system.Threshold = reader.Threshold
+	system.InitialTiles = make(TileMap)
+
+	for {
+		pos, tile, err := reader.ReadTile()
+
+		if err == io.EOF {
+			return system, nil
+		} else if err != nil {
+			return system, err
+		}
+
+		system.InitialTiles[pos] = tile
+	}
+}
+
+// Converts the binary encoding to the JSON one of TileSystem, tiles are streamed
+// so that only the tile set is kept in memory
+func ConvertBinaryToJSON(r io.Reader, w io.Writer) error {
+	reader, err := NewBinaryReader(r)
+
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	header := struct {
+		TileSet       TileSet       `json:"tile_set"`
+		GlueStrengths GlueStrengths `json:"glue_strengths,omitempty"`
+		Lattice       Lattice       `json:"lattice,omitempty"`
+	}{reader.TileSet, reader.GlueStrengths, reader.Lattice}
+
+	b, err := json.Marshal(header)
+
+	if err != nil {
+		return err
+	}
+
+	// Reopening the object to append the tiles and the threshold
+	bw.Write(b[:len(b)-1])
+	bw.WriteString(`,"tile_map":{`)
+
+	for first := true; ; first = false {
+		pos, tile, err := reader.ReadTile()
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if !first {
+			bw.WriteByte(',')
+		}
+
+		marshaledPos, err := json.Marshal(pos)
+
+		if err != nil {
+			return err
+		}
+
+		marshaledTile, err := json.Marshal(tile)
+
+		if err != nil {
+			return err
+		}
+
+		// The position is the key, encoded as in TileMap.MarshalJSON
+		marshaledKey, err := json.Marshal(string(marshaledPos))
+
+		if err != nil {
+			return err
+		}
+
+		bw.Write(marshaledKey)
+		bw.WriteByte(':')
+		bw.Write(marshaledTile)
+	}
+
+	fmt.Fprintf(bw, `},"threshold":%d}`+"\n", reader.Threshold)
+
+	return bw.Flush()
+}
+
+// Converts the JSON encoding of TileSystem to the binary one. The JSON document is
+// decoded in memory since the threshold may come after the tiles.
+func ConvertJSONToBinary(r io.Reader, w io.Writer) error {
+	var system TileSystem
+
+	if err := json.NewDecoder(r).Decode(&system); err != nil {
+		return err
+	}
+
+	return WriteTileSystemBinary(w, system)
+}
@@ -0,0 +1,81 @@
+package tamtam
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	assembly := grownCrtAssembly(t, 30)
+	system := assembly.GetTileSystem()
+	system.GlueStrengths = GlueStrengths{"0": 2}
+
+	var buffer bytes.Buffer
+
+	if err := WriteTileSystemBinary(&buffer, system); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	encoded := buffer.Bytes()
+
+	if reloaded, err := ReadTileSystemBinary(bytes.NewReader(encoded)); err != nil || !system.IsEqualTo(reloaded) {
+		t.Fatalf(`Binary round trip gave %v, %v`, reloaded, err)
+	}
+
+	marshaled, err := json.Marshal(system)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if len(encoded)*4 > len(marshaled) {
+		t.Fatalf(`Binary encoding takes %d bytes, JSON %d bytes`, len(encoded), len(marshaled))
+	}
+
+	var converted bytes.Buffer
+
+	if err := ConvertBinaryToJSON(bytes.NewReader(encoded), &converted); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	var fromJSON TileSystem
+
+	if err := json.Unmarshal(converted.Bytes(), &fromJSON); err != nil || !system.IsEqualTo(fromJSON) {
+		t.Fatalf(`Converted JSON gave %v, %v`, fromJSON, err)
+	}
+
+	var backToBinary bytes.Buffer
+
+	if err := ConvertJSONToBinary(&converted, &backToBinary); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !bytes.Equal(backToBinary.Bytes(), encoded) {
+		t.Fatalf(`Converting back to binary gave a different encoding`)
+	}
+
+	// Truncated streams must be reported
+	if _, err := ReadTileSystemBinary(bytes.NewReader(encoded[:len(encoded)-1])); err == nil {
+		t.Fatalf(`Expected an error for a truncated stream`)
+	}
+}
+
+// Parts of the growth model the format cannot hold are reported instead of being dropped
+func TestWriteTileSystemBinaryErrors(t *testing.T) {
+	tile := SquareGlues{"a", "a", "a", "a"}
+
+	for _, model := range []GrowthModel{
+		{Geometry: &Geometry{Kind: GEOMETRY_TORUS, UpperRight: Vec2Di{3, 3}}},
+		{Orientations: ORIENTATIONS_ROTATIONS},
+		{SignalTileSet: SignalTileSet{"s": {Glues: SquareGlues{"b"}}}},
+		{Detachment: DETACHMENT_UNSTABLE},
+		{TileConcentrations: TileConcentrations{"a": 2}},
+	} {
+		system := TileSystem{TileSet: TileSet{"a": tile}, InitialTiles: TileMap{{0, 0}: tile}, Threshold: 1, GrowthModel: model}
+
+		if err := WriteTileSystemBinary(&bytes.Buffer{}, system); err == nil {
+			t.Fatalf(`No error for the growth model %+v`, model)
+		}
+	}
+}
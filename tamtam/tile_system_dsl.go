@@ -0,0 +1,745 @@
+package tamtam
+
+// Text description of tile systems, an alternative to writing tile sets in JSON by hand:
+//
+//	# Comments start with '#'
+//	threshold 2                  # defaults to 2
+//	let n = 20                   # variables, usable as {n} in any word
+//	family bit = 0 1             # named list of glues
+//	strength bit 1               # strength of a glue, or of all the glues of a family
+//	strength seed 2
+//
+//	tile corner seed seed - -    # name followed by the north, east, south and west glues, '-' being no glue
+//
+//	for b in bit {               # loops over the glues of a family...
+//	    for c in 0..1 {          # ...or over an inclusive range of integers
+//	        tile add_{b}_{c} {(b+c)%2} {b*c} {b} {c}
+//	    }
+//	}
+//
+//	seed 0 0 corner              # tile of the tile set placed at x = 0, y = 0...
+//	for i in 1..{n-1} {
+//	    seed {i} 0 - - - seed    # ...or tile given by its glues
+//	}
+//
+// Inside braces, a lone variable is replaced by its value and anything else is evaluated
+// as an integer expression with + - * / % and parentheses. Words containing spaces or
+// special characters can be written between double quotes, no substitution happens then.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const DSL_DEFAULT_THRESHOLD = 2
+const DSL_NULL_GLUE = "-"
+
+// Error in a tile system description, with the position where it was found
+type DSLError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (err DSLError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", err.Line, err.Column, err.Message)
+}
+
+type dslTokenKind int
+
+const (
+	dslWord dslTokenKind = iota
+	dslBlockOpen
+	dslBlockClose
+)
+
+type dslToken struct {
+	kind   dslTokenKind
+	text   string
+	quoted bool
+	line   int
+	column int
+}
+
+func (token dslToken) errorf(format string, a ...interface{}) DSLError {
+	return DSLError{Line: token.line, Column: token.column, Message: fmt.Sprintf(format, a...)}
+}
+
+// A statement is a line of the description, for loops have a body
+type dslStatement struct {
+	keyword dslToken
+	args    []dslToken
+	body    []dslStatement
+	// Closing brace of a for loop, for error messages
+	end dslToken
+}
+
+// Splits each line into tokens, words containing {expressions} are kept whole
+func tokenizeDSL(r io.Reader) (lines [][]dslToken, err error) {
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber += 1
+		line := []rune(scanner.Text())
+		var tokens []dslToken
+
+		for i := 0; i < len(line); {
+			if unicode.IsSpace(line[i]) {
+				i += 1
+				continue
+			}
+
+			if line[i] == '#' {
+				break
+			}
+
+			start := i
+			token := dslToken{kind: dslWord, line: lineNumber, column: start + 1}
+			isDelimiter := func(j int) bool { return j >= len(line) || unicode.IsSpace(line[j]) }
+
+			switch {
+			case line[i] == '{' && isDelimiter(i+1):
+				token.kind = dslBlockOpen
+				token.text = "{"
+				i += 1
+
+			case line[i] == '}' && isDelimiter(i+1):
+				token.kind = dslBlockClose
+				token.text = "}"
+				i += 1
+
+			case line[i] == '"':
+				token.quoted = true
+				var text strings.Builder
+				i += 1
+				for ; i < len(line) && line[i] != '"'; i += 1 {
+					if line[i] == '\\' && i+1 < len(line) {
+						i += 1
+					}
+					text.WriteRune(line[i])
+				}
+				if i >= len(line) {
+					return nil, token.errorf("unterminated quoted word")
+				}
+				i += 1
+				token.text = text.String()
+
+			default:
+				for i < len(line) && !unicode.IsSpace(line[i]) {
+					if line[i] == '{' {
+						depth := 0
+						for ; i < len(line); i += 1 {
+							if line[i] == '{' {
+								depth += 1
+							} else if line[i] == '}' {
+								depth -= 1
+								if depth == 0 {
+									break
+								}
+							}
+						}
+						if i >= len(line) {
+							return nil, DSLError{Line: lineNumber, Column: start + 1, Message: "unterminated '{' in word"}
+						}
+					}
+					i += 1
+				}
+				token.text = string(line[start:i])
+			}
+
+			tokens = append(tokens, token)
+		}
+
+		lines = append(lines, tokens)
+	}
+
+	return lines, scanner.Err()
+}
+
+// Groups lines into statements, the body of for loops being delimited by braces
+func parseDSLBlock(lines [][]dslToken, index *int, opening *dslToken) (statements []dslStatement, end dslToken, err error) {
+	for ; *index < len(lines); *index += 1 {
+		tokens := lines[*index]
+
+		if len(tokens) == 0 {
+			continue
+		}
+
+		if tokens[0].kind == dslBlockClose {
+			if opening == nil {
+				return nil, end, tokens[0].errorf("unexpected '}'")
+			}
+			if len(tokens) > 1 {
+				return nil, end, tokens[1].errorf("unexpected %q after '}'", tokens[1].text)
+			}
+			return statements, tokens[0], nil
+		}
+
+		if tokens[0].kind != dslWord || tokens[0].quoted {
+			return nil, end, tokens[0].errorf("expected a keyword, got %q", tokens[0].text)
+		}
+
+		statement := dslStatement{keyword: tokens[0], args: tokens[1:]}
+
+		if statement.keyword.text == "for" {
+			last := tokens[len(tokens)-1]
+			if last.kind != dslBlockOpen {
+				return nil, end, last.errorf("for loop must end with '{'")
+			}
+			statement.args = tokens[1 : len(tokens)-1]
+			*index += 1
+			statement.body, statement.end, err = parseDSLBlock(lines, index, &last)
+			if err != nil {
+				return nil, end, err
+			}
+		}
+
+		for _, arg := range statement.args {
+			if arg.kind != dslWord {
+				return nil, end, arg.errorf("unexpected %q", arg.text)
+			}
+		}
+
+		statements = append(statements, statement)
+	}
+
+	if opening != nil {
+		return nil, end, opening.errorf("'{' is never closed")
+	}
+
+	return statements, end, nil
+}
+
+// Integer expression evaluation inside braces
+type dslExpression struct {
+	text      []rune
+	position  int
+	variables map[string]string
+	token     dslToken
+}
+
+func (expr *dslExpression) skipSpaces() {
+	for expr.position < len(expr.text) && unicode.IsSpace(expr.text[expr.position]) {
+		expr.position += 1
+	}
+}
+
+func (expr *dslExpression) peek() rune {
+	expr.skipSpaces()
+	if expr.position >= len(expr.text) {
+		return 0
+	}
+	return expr.text[expr.position]
+}
+
+func (expr *dslExpression) parseSum() (int, error) {
+	value, err := expr.parseProduct()
+	for err == nil {
+		operator := expr.peek()
+		if operator != '+' && operator != '-' {
+			break
+		}
+		expr.position += 1
+		var operand int
+		if operand, err = expr.parseProduct(); err != nil {
+			break
+		}
+		if operator == '+' {
+			value += operand
+		} else {
+			value -= operand
+		}
+	}
+	return value, err
+}
+
+func (expr *dslExpression) parseProduct() (int, error) {
+	value, err := expr.parseFactor()
+	for err == nil {
+		operator := expr.peek()
+		if operator != '*' && operator != '/' && operator != '%' {
+			break
+		}
+		expr.position += 1
+		var operand int
+		if operand, err = expr.parseFactor(); err != nil {
+			break
+		}
+		switch {
+		case operator == '*':
+			value *= operand
+		case operand == 0:
+			return 0, expr.token.errorf("division by zero in {%s}", string(expr.text))
+		case operator == '/':
+			value /= operand
+		default:
+			value %= operand
+		}
+	}
+	return value, err
+}
+
+func (expr *dslExpression) parseFactor() (int, error) {
+	char := expr.peek()
+
+	switch {
+	case char == '-':
+		expr.position += 1
+		value, err := expr.parseFactor()
+		return -value, err
+
+	case char == '(':
+		expr.position += 1
+		value, err := expr.parseSum()
+		if err != nil {
+			return 0, err
+		}
+		if expr.peek() != ')' {
+			return 0, expr.token.errorf("missing ')' in {%s}", string(expr.text))
+		}
+		expr.position += 1
+		return value, nil
+
+	case unicode.IsDigit(char):
+		start := expr.position
+		for expr.position < len(expr.text) && unicode.IsDigit(expr.text[expr.position]) {
+			expr.position += 1
+		}
+		return strconv.Atoi(string(expr.text[start:expr.position]))
+
+	case unicode.IsLetter(char) || char == '_':
+		start := expr.position
+		for expr.position < len(expr.text) && (unicode.IsLetter(expr.text[expr.position]) || unicode.IsDigit(expr.text[expr.position]) || expr.text[expr.position] == '_') {
+			expr.position += 1
+		}
+		name := string(expr.text[start:expr.position])
+		value, ok := expr.variables[name]
+		if !ok {
+			return 0, expr.token.errorf("unknown variable %q", name)
+		}
+		intValue, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, expr.token.errorf("variable %q is %q which is not an integer", name, value)
+		}
+		return intValue, nil
+	}
+
+	return 0, expr.token.errorf("invalid expression {%s}", string(expr.text))
+}
+
+// State of the interpretation of a description
+type dslInterpreter struct {
+	system    TileSystem
+	variables map[string]string
+	families  map[string][]string
+	// Seeds given by tile name are resolved at the end since tiles can be declared after them
+	namedSeeds     []dslNamedSeed
+	seedPositions  map[Vec2Di]dslToken
+	tileNameTokens map[string]dslToken
+}
+
+type dslNamedSeed struct {
+	pos   Vec2Di
+	name  dslToken
+	value string
+}
+
+// Replaces the {expressions} of the word by their value
+func (interpreter *dslInterpreter) expand(token dslToken) (string, error) {
+	if token.quoted {
+		return token.text, nil
+	}
+
+	var expanded strings.Builder
+	text := []rune(token.text)
+
+	for i := 0; i < len(text); i += 1 {
+		if text[i] != '{' {
+			expanded.WriteRune(text[i])
+			continue
+		}
+
+		depth := 0
+		end := i
+		for ; end < len(text); end += 1 {
+			if text[end] == '{' {
+				depth += 1
+			} else if text[end] == '}' {
+				depth -= 1
+				if depth == 0 {
+					break
+				}
+			}
+		}
+
+		inside := strings.TrimSpace(string(text[i+1 : end]))
+		i = end
+
+		if value, ok := interpreter.variables[inside]; ok {
+			expanded.WriteString(value)
+			continue
+		}
+
+		expr := dslExpression{text: []rune(inside), variables: interpreter.variables, token: token}
+		value, err := expr.parseSum()
+		if err != nil {
+			return "", err
+		}
+		if expr.peek() != 0 {
+			return "", token.errorf("invalid expression {%s}", inside)
+		}
+		expanded.WriteString(strconv.Itoa(value))
+	}
+
+	return expanded.String(), nil
+}
+
+func (interpreter *dslInterpreter) expandInt(token dslToken) (int, error) {
+	text, err := interpreter.expand(token)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, token.errorf("expected an integer, got %q", text)
+	}
+	return value, nil
+}
+
+func (interpreter *dslInterpreter) expandGlue(token dslToken) (string, error) {
+	if !token.quoted && token.text == DSL_NULL_GLUE {
+		return NULL_GLUE, nil
+	}
+	return interpreter.expand(token)
+}
+
+func (interpreter *dslInterpreter) expandGlues(tokens []dslToken) (glues SquareGlues, err error) {
+	for i, token := range tokens {
+		if glues[i], err = interpreter.expandGlue(token); err != nil {
+			return glues, err
+		}
+	}
+	return glues, nil
+}
+
+func checkArgsCount(statement dslStatement, counts ...int) error {
+	for _, count := range counts {
+		if len(statement.args) == count {
+			return nil
+		}
+	}
+	return statement.keyword.errorf("wrong number of arguments for %q", statement.keyword.text)
+}
+
+func (interpreter *dslInterpreter) setStrength(token dslToken, glue string, strength int) error {
+	if interpreter.system.GlueStrengths == nil {
+		interpreter.system.GlueStrengths = make(GlueStrengths)
+	}
+	if previous, ok := interpreter.system.GlueStrengths[glue]; ok && previous != strength {
+		return token.errorf("glue %q already has strength %d", glue, previous)
+	}
+	interpreter.system.GlueStrengths[glue] = strength
+	return nil
+}
+
+func (interpreter *dslInterpreter) run(statements []dslStatement) error {
+	for _, statement := range statements {
+		if err := interpreter.runStatement(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (interpreter *dslInterpreter) runStatement(statement dslStatement) (err error) {
+	args := statement.args
+
+	switch statement.keyword.text {
+	case "threshold":
+		if err := checkArgsCount(statement, 1); err != nil {
+			return err
+		}
+		interpreter.system.Threshold, err = interpreter.expandInt(args[0])
+		return err
+
+	case "let":
+		if len(args) != 3 || args[1].text != "=" {
+			return statement.keyword.errorf("expected let <name> = <value>")
+		}
+		value, err := interpreter.expand(args[2])
+		if err != nil {
+			return err
+		}
+		interpreter.variables[args[0].text] = value
+
+	case "family":
+		if len(args) < 2 || args[1].text != "=" {
+			return statement.keyword.errorf("expected family <name> = <glue> <glue> ...")
+		}
+		var glues []string
+		for _, token := range args[2:] {
+			glue, err := interpreter.expand(token)
+			if err != nil {
+				return err
+			}
+			glues = append(glues, glue)
+		}
+		interpreter.families[args[0].text] = glues
+
+	case "strength":
+		if err := checkArgsCount(statement, 2); err != nil {
+			return err
+		}
+		glue, err := interpreter.expand(args[0])
+		if err != nil {
+			return err
+		}
+		strength, err := interpreter.expandInt(args[1])
+		if err != nil {
+			return err
+		}
+		glues, isFamily := interpreter.families[glue]
+		if !isFamily || args[0].quoted {
+			glues = []string{glue}
+		}
+		for _, glue := range glues {
+			if err := interpreter.setStrength(args[0], glue, strength); err != nil {
+				return err
+			}
+		}
+
+	case "tile":
+		if err := checkArgsCount(statement, 5); err != nil {
+			return err
+		}
+		name, err := interpreter.expand(args[0])
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			return args[0].errorf("empty tile name")
+		}
+		if previous, ok := interpreter.tileNameTokens[name]; ok {
+			return args[0].errorf("tile %q is already declared at %d:%d", name, previous.line, previous.column)
+		}
+		glues, err := interpreter.expandGlues(args[1:])
+		if err != nil {
+			return err
+		}
+		interpreter.system.TileSet[name] = glues
+		interpreter.tileNameTokens[name] = args[0]
+
+	case "seed":
+		if err := checkArgsCount(statement, 3, 6); err != nil {
+			return err
+		}
+		var pos Vec2Di
+		for i := 0; i < 2; i += 1 {
+			if pos[i], err = interpreter.expandInt(args[i]); err != nil {
+				return err
+			}
+		}
+		if previous, ok := interpreter.seedPositions[pos]; ok {
+			return args[0].errorf("position %v already has a seed tile, set at %d:%d", pos, previous.line, previous.column)
+		}
+		interpreter.seedPositions[pos] = args[0]
+
+		if len(args) == 3 {
+			name, err := interpreter.expand(args[2])
+			if err != nil {
+				return err
+			}
+			interpreter.namedSeeds = append(interpreter.namedSeeds, dslNamedSeed{pos: pos, name: args[2], value: name})
+			return nil
+		}
+
+		glues, err := interpreter.expandGlues(args[2:])
+		if err != nil {
+			return err
+		}
+		interpreter.system.InitialTiles[pos] = glues
+
+	case "for":
+		return interpreter.runFor(statement)
+
+	default:
+		return statement.keyword.errorf("unknown keyword %q", statement.keyword.text)
+	}
+
+	return nil
+}
+
+func (interpreter *dslInterpreter) runFor(statement dslStatement) error {
+	args := statement.args
+
+	if len(args) != 3 || args[1].text != "in" {
+		return statement.keyword.errorf("expected for <variable> in <family> {, or for <variable> in <start>..<end> {")
+	}
+
+	iterated, err := interpreter.expand(args[2])
+	if err != nil {
+		return err
+	}
+
+	var values []string
+
+	if glues, ok := interpreter.families[iterated]; ok && !args[2].quoted {
+		values = glues
+	} else if bounds := strings.SplitN(iterated, "..", 2); len(bounds) == 2 {
+		start, errStart := strconv.Atoi(bounds[0])
+		end, errEnd := strconv.Atoi(bounds[1])
+		if errStart != nil || errEnd != nil {
+			return args[2].errorf("invalid range %q", iterated)
+		}
+		for i := start; i <= end; i += 1 {
+			values = append(values, strconv.Itoa(i))
+		}
+	} else {
+		return args[2].errorf("%q is neither a family nor a range", iterated)
+	}
+
+	variable := args[0].text
+	previous, hadPrevious := interpreter.variables[variable]
+
+	for _, value := range values {
+		interpreter.variables[variable] = value
+		if err := interpreter.run(statement.body); err != nil {
+			return err
+		}
+	}
+
+	if hadPrevious {
+		interpreter.variables[variable] = previous
+	} else {
+		delete(interpreter.variables, variable)
+	}
+
+	return nil
+}
+
+// Parses a tile system description, errors are of type DSLError when the description is invalid
+func ParseTileSystemDSL(r io.Reader) (system TileSystem, err error) {
+	lines, err := tokenizeDSL(r)
+
+	if err != nil {
+		return system, err
+	}
+
+	index := 0
+	statements, _, err := parseDSLBlock(lines, &index, nil)
+
+	if err != nil {
+		return system, err
+	}
+
+	interpreter := dslInterpreter{
+		system:         TileSystem{TileSet: make(TileSet), InitialTiles: make(TileMap), Threshold: DSL_DEFAULT_THRESHOLD},
+		variables:      make(map[string]string),
+		families:       make(map[string][]string),
+		seedPositions:  make(map[Vec2Di]dslToken),
+		tileNameTokens: make(map[string]dslToken),
+	}
+
+	if err := interpreter.run(statements); err != nil {
+		return system, err
+	}
+
+	for _, seed := range interpreter.namedSeeds {
+		tile, ok := interpreter.system.TileSet[seed.value]
+		if !ok {
+			return system, seed.name.errorf("unknown tile %q", seed.value)
+		}
+		interpreter.system.InitialTiles[seed.pos] = tile
+	}
+
+	return interpreter.system, nil
+}
+
+// Quotes the word if it would not be read back as is
+func formatDSLWord(word string) string {
+	needsQuotes := word == "" || word == DSL_NULL_GLUE || strings.HasPrefix(word, "#")
+	for _, char := range word {
+		if unicode.IsSpace(char) || strings.ContainsRune("{}\"\\", char) {
+			needsQuotes = true
+		}
+	}
+
+	if !needsQuotes {
+		return word
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(word)
+	return `"` + escaped + `"`
+}
+
+func formatDSLGlues(glues SquareGlues) string {
+	formatted := make([]string, 4)
+	for i, glue := range glues[:4] {
+		if glue == NULL_GLUE {
+			formatted[i] = DSL_NULL_GLUE
+		} else {
+			formatted[i] = formatDSLWord(glue)
+		}
+	}
+	return strings.Join(formatted, " ")
+}
+
+// Writes the tile system as a description that ParseTileSystemDSL reads back to the same
+// tile system. Tiles and seeds are listed one by one, loops and families are not recovered.
+func WriteTileSystemDSL(w io.Writer, system TileSystem) error {
+	if err := system.checkSquareLattice("tile system description language"); err != nil {
+		return err
+	}
+
+	if err := system.checkNoConcentrations("tile system description language"); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "threshold %d\n", system.Threshold)
+
+	glues := make([]string, 0, len(system.GlueStrengths))
+	for glue := range system.GlueStrengths {
+		glues = append(glues, glue)
+	}
+	sort.Strings(glues)
+
+	if len(glues) > 0 {
+		fmt.Fprintln(bw)
+	}
+	for _, glue := range glues {
+		fmt.Fprintf(bw, "strength %s %d\n", formatDSLWord(glue), system.GlueStrengths[glue])
+	}
+
+	if len(system.TileSet) > 0 {
+		fmt.Fprintln(bw)
+	}
+	for _, name := range system.TileSet.SortedNames() {
+		fmt.Fprintf(bw, "tile %s %s\n", formatDSLWord(name), formatDSLGlues(system.TileSet[name]))
+	}
+
+	positions := system.InitialTiles.SortedPositions()
+
+	tileNames := system.TileSet.TileNames()
+
+	if len(positions) > 0 {
+		fmt.Fprintln(bw)
+	}
+	for _, pos := range positions {
+		tile := system.InitialTiles[pos]
+		if name, ok := tileNames[tile]; ok {
+			fmt.Fprintf(bw, "seed %d %d %s\n", pos[0], pos[1], formatDSLWord(name))
+		} else {
+			fmt.Fprintf(bw, "seed %d %d %s\n", pos[0], pos[1], formatDSLGlues(tile))
+		}
+	}
+
+	return bw.Flush()
+}
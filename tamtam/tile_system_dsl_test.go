@@ -0,0 +1,169 @@
+package tamtam
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const CRT_DSL = `
+# Chinese Remainder tile set with p = 2 and q = 3
+threshold 2
+let size = 20
+
+for i in 0..5 {
+	tile {i} {i/3} {i%3} {i%2} {i/2}
+}
+
+for i in 0..{size-1} {
+	seed -1 {i} - 0 - -
+}
+
+seed 0 -1 1 - - -
+for i in 1..{size-1} {
+	seed {i} -1 0 - - -
+}
+`
+
+// Testing that the description of the CRT system gives the same assembly as the Go code
+func TestParseTileSystemDSL(t *testing.T) {
+	system, err := ParseTileSystemDSL(strings.NewReader(CRT_DSL))
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	tileSet, err := NewCrtTileSet(2, 3)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !system.TileSet.IsEqualTo(tileSet) {
+		t.Fatalf(`Parsed tile set %v differs from %v`, system.TileSet, tileSet)
+	}
+
+	assembly, err := system.NewAssembly()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	didGrow, err := assembly.GrowSync(true)
+
+	for didGrow && err == nil {
+		didGrow, err = assembly.GrowSync(true)
+	}
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if assembly.Size() != 20*20+2*20 {
+		t.Fatalf(`Assembly size %d != %d`, assembly.Size(), 20*20+2*20)
+	}
+}
+
+func TestParseTileSystemDSLFamiliesAndStrengths(t *testing.T) {
+	system, err := ParseTileSystemDSL(strings.NewReader(`
+threshold 2
+family bit = 0 1
+strength bit 1
+strength "seed glue" 2
+for b in bit {
+	for c in bit {
+		tile add_{b}_{c} {(b+c)%2} {b*c} {b} {c}
+	}
+}
+tile start "seed glue" - - -
+seed 0 0 start
+`))
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if len(system.TileSet) != 5 || system.TileSet["add_1_1"] != (SquareGlues{"0", "1", "1", "1"}) {
+		t.Fatalf(`Unexpected tile set %v`, system.TileSet)
+	}
+
+	if system.GlueStrengths.Strength("seed glue") != 2 || system.GlueStrengths.Strength("1") != 1 {
+		t.Fatalf(`Unexpected glue strengths %v`, system.GlueStrengths)
+	}
+
+	if system.InitialTiles[Vec2Di{0, 0}] != system.TileSet["start"] {
+		t.Fatalf(`Seed tile should be "start", got %v`, system.InitialTiles)
+	}
+}
+
+// Testing that errors are reported at the right line and column
+func TestParseTileSystemDSLErrors(t *testing.T) {
+	cases := []struct {
+		description string
+		line        int
+		column      int
+	}{
+		{"threshold two", 1, 11},
+		{"tile a 1 2 3", 1, 1},
+		{"tile a 1 2 3 4\n  tile a 1 2 3 4", 2, 8},
+		{"for i in 0..2 {\n  tile t{i} {i+} - - -\n}", 2, 13},
+		{"for i in 0..2 {\n  tile t{i} - - - -\n", 1, 15},
+		{"}", 1, 1},
+		{"seed 0 0 nowhere", 1, 10},
+		{"seed 0 0 - - - a\nseed 0 {1-1} - - - a", 2, 6},
+		{"strength a 1\nstrength a 2", 2, 10},
+		{"tile t {j} - - -", 1, 8},
+		{"tile \"t - - - -", 1, 6},
+		{"grow 3", 1, 1},
+	}
+
+	for _, c := range cases {
+		_, err := ParseTileSystemDSL(strings.NewReader(c.description))
+
+		dslErr, ok := err.(DSLError)
+
+		if !ok {
+			t.Fatalf(`Expected a DSLError for %q, got %v`, c.description, err)
+		}
+
+		if dslErr.Line != c.line || dslErr.Column != c.column {
+			t.Fatalf(`Error for %q is at %d:%d instead of %d:%d (%v)`, c.description, dslErr.Line, dslErr.Column, c.line, c.column, dslErr)
+		}
+	}
+}
+
+// Testing that printing a tile system and parsing it back gives the same tile system
+func TestTileSystemDSLRoundTrip(t *testing.T) {
+	system := TileSystem{
+		TileSet: TileSet{
+			"plain":          {"a", "b", NULL_GLUE, "c"},
+			"with space":     {"glue with space", "-", "#hash", `quote"and\backslash`},
+			"{braces}":       {"{", "}", "{x}", "-"},
+			"10":             {"1", "0", NULL_GLUE, NULL_GLUE},
+			"2":              {"0", "1", NULL_GLUE, NULL_GLUE},
+			"#not a comment": {"x", NULL_GLUE, NULL_GLUE, NULL_GLUE},
+		},
+		GlueStrengths: GlueStrengths{"a": 2, "glue with space": 0, "-": 3},
+		InitialTiles: TileMap{
+			{0, 0}:  {"a", "b", NULL_GLUE, "c"},
+			{-3, 7}: {NULL_GLUE, "not in the tile set", NULL_GLUE, NULL_GLUE},
+		},
+		Threshold: 3,
+	}
+
+	var b bytes.Buffer
+
+	if err := WriteTileSystemDSL(&b, system); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	parsed, err := ParseTileSystemDSL(&b)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !parsed.IsEqualTo(system) {
+		t.Fatalf(`Round trip changed the tile system: %v != %v`, parsed, system)
+	}
+}
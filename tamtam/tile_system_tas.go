@@ -0,0 +1,485 @@
+package tamtam
+
+// Reading and writing the tile set formats of ISU TAS and PyTAS.
+//
+// ISU TAS tile definitions (.tds) list tiles as blocks of KEY value lines closed by CREATE:
+//
+//	TILENAME corner
+//	LABEL C
+//	TILECOLOR red
+//	NORTHBIND 2
+//	EASTBIND 2
+//	SOUTHBIND 0
+//	WESTBIND 0
+//	NORTHLABEL v
+//	EASTLABEL h
+//	SOUTHLABEL
+//	WESTLABEL
+//	CREATE
+//
+// A side binds with its label when its strength is not 0. Strengths are per side in TAS and
+// per glue in tamtam: a label used with two different strengths is an error.
+//
+// ISU TAS assembly files (.tdp) give the tile definition file on their first line (relative to
+// the .tdp file), followed by seed tiles as "<tile name> <x> <y>" lines. The threshold is given
+// by a "TEMPERATURE <n>" line, 2 if there is none.
+//
+// PyTAS tile sets are JSON documents:
+//
+//	{
+//	  "temperature": 2,
+//	  "glues": [{"label": "v", "strength": 2}],
+//	  "tiles": [{"name": "corner", "label": "C", "color": "red", "glues": ["v", "h", "", ""]}],
+//	  "seed": [{"tile": "corner", "x": 0, "y": 0}]
+//	}
+//
+// Glues are in the tamtam order (north, east, south, west) and glues missing from "glues" have strength 1.
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const TAS_DEFAULT_THRESHOLD = 2
+
+var tasSideNames = [4]string{"NORTH", "EAST", "SOUTH", "WEST"}
+
+// Seed tile of TAS and PyTAS files, given by its tile name
+type TASSeedTile struct {
+	Tile string `json:"tile"`
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+}
+
+// Reads an ISU TAS tile definition file
+func ReadTDS(r io.Reader) (tileSet TileSet, strengths GlueStrengths, err error) {
+	tileSet = make(TileSet)
+	strengths = make(GlueStrengths)
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	var name string
+	var labels [4]string
+	var binds [4]int
+
+	reset := func() {
+		name = ""
+		labels = [4]string{}
+		binds = [4]int{}
+	}
+
+	for scanner.Scan() {
+		lineNumber += 1
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			key, value = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		key = strings.ToUpper(key)
+
+		if key == "CREATE" {
+			if name == "" {
+				return nil, nil, fmt.Errorf("line %d: tile without TILENAME", lineNumber)
+			}
+			if _, ok := tileSet[name]; ok {
+				return nil, nil, fmt.Errorf("line %d: tile %q is defined twice", lineNumber, name)
+			}
+
+			var tile SquareGlues
+			for side := 0; side < 4; side += 1 {
+				if binds[side] == 0 {
+					continue
+				}
+				if labels[side] == NULL_GLUE {
+					return nil, nil, fmt.Errorf("line %d: %s side of tile %q binds without label", lineNumber, strings.ToLower(tasSideNames[side]), name)
+				}
+				if previous, ok := strengths[labels[side]]; ok && previous != binds[side] {
+					return nil, nil, fmt.Errorf("line %d: label %q is used with strengths %d and %d", lineNumber, labels[side], previous, binds[side])
+				}
+				strengths[labels[side]] = binds[side]
+				tile[side] = labels[side]
+			}
+
+			tileSet[name] = tile
+			reset()
+			continue
+		}
+
+		if key == "TILENAME" {
+			name = value
+			continue
+		}
+
+		for side, sideName := range tasSideNames {
+			switch key {
+			case sideName + "BIND":
+				if binds[side], err = strconv.Atoi(value); err != nil {
+					return nil, nil, fmt.Errorf("line %d: invalid strength %q", lineNumber, value)
+				}
+			case sideName + "LABEL":
+				labels[side] = value
+			}
+		}
+
+		// Other keys (LABEL, TILECOLOR, TEXTCOLOR, CONCENTRATION...) only matter to TAS display
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if name != "" {
+		return nil, nil, fmt.Errorf("line %d: tile %q is not followed by CREATE", lineNumber, name)
+	}
+
+	// Strength 1 is the default in tamtam
+	for glue, strength := range strengths {
+		if strength == 1 {
+			delete(strengths, glue)
+		}
+	}
+
+	return tileSet, strengths, nil
+}
+
+// Writes the tile set as an ISU TAS tile definition file, tiles sorted by name.
+// Glues of strength 0 cannot be represented and are lost.
+func WriteTDS(w io.Writer, tileSet TileSet, strengths GlueStrengths) error {
+	if err := LATTICE_SQUARE.checkTiles(tileSet, nil); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	for i, name := range tileSet.SortedNames() {
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+
+		tile := tileSet[name]
+		fmt.Fprintln(bw, "TILENAME", name)
+		fmt.Fprintln(bw, "LABEL", name)
+		fmt.Fprintln(bw, "TILECOLOR white")
+		for side, sideName := range tasSideNames {
+			fmt.Fprintf(bw, "%sBIND %d\n", sideName, strengths.Strength(tile[side]))
+		}
+		for side, sideName := range tasSideNames {
+			fmt.Fprintf(bw, "%sLABEL %s\n", sideName, tile[side])
+		}
+		fmt.Fprintln(bw, "CREATE")
+	}
+
+	return bw.Flush()
+}
+
+// Reads an ISU TAS assembly file, returns the path of its tile definition file as written in it
+func ReadTDP(r io.Reader) (tdsPath string, seed []TASSeedTile, threshold int, err error) {
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	threshold = TAS_DEFAULT_THRESHOLD
+
+	for scanner.Scan() {
+		lineNumber += 1
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if tdsPath == "" {
+			tdsPath = line
+			continue
+		}
+
+		fields := strings.Fields(strings.Replace(line, "=", " ", 1))
+
+		if len(fields) == 0 {
+			return "", nil, 0, fmt.Errorf("line %d: expected TEMPERATURE <n> or <tile name> <x> <y>", lineNumber)
+		}
+
+		if strings.ToUpper(fields[0]) == "TEMPERATURE" {
+			if len(fields) != 2 {
+				return "", nil, 0, fmt.Errorf("line %d: expected TEMPERATURE <n>", lineNumber)
+			}
+			if threshold, err = strconv.Atoi(fields[1]); err != nil {
+				return "", nil, 0, fmt.Errorf("line %d: invalid temperature %q", lineNumber, fields[1])
+			}
+			continue
+		}
+
+		fields = strings.Fields(line)
+
+		if len(fields) < 3 {
+			return "", nil, 0, fmt.Errorf("line %d: expected <tile name> <x> <y>", lineNumber)
+		}
+
+		x, errX := strconv.Atoi(fields[len(fields)-2])
+		y, errY := strconv.Atoi(fields[len(fields)-1])
+
+		if errX != nil || errY != nil {
+			return "", nil, 0, fmt.Errorf("line %d: invalid seed position", lineNumber)
+		}
+
+		seed = append(seed, TASSeedTile{Tile: strings.Join(fields[:len(fields)-2], " "), X: x, Y: y})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", nil, 0, err
+	}
+
+	if tdsPath == "" {
+		return "", nil, 0, errors.New("missing tile definition file")
+	}
+
+	return tdsPath, seed, threshold, nil
+}
+
+// Writes an ISU TAS assembly file referring to the given tile definition file
+func WriteTDP(w io.Writer, tdsPath string, seed []TASSeedTile, threshold int) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, tdsPath)
+	fmt.Fprintln(bw, "TEMPERATURE", threshold)
+	for _, seedTile := range seed {
+		fmt.Fprintln(bw, seedTile.Tile, seedTile.X, seedTile.Y)
+	}
+
+	return bw.Flush()
+}
+
+// Places the seed tiles, which must all be in the tile set
+func seedTileMap(tileSet TileSet, seed []TASSeedTile) (TileMap, error) {
+	initialTiles := make(TileMap)
+
+	for _, seedTile := range seed {
+		tile, ok := tileSet[seedTile.Tile]
+		if !ok {
+			return nil, fmt.Errorf("seed tile %q is not in the tile set", seedTile.Tile)
+		}
+		initialTiles[Vec2Di{seedTile.X, seedTile.Y}] = tile
+	}
+
+	return initialTiles, nil
+}
+
+// Lists the initial tiles by name. TAS formats can only place tiles of the tile set: initial
+// tiles that are not in it are added to the returned tile set with names seed_0, seed_1, ...
+func namedSeed(system TileSystem) (TileSet, []TASSeedTile) {
+	tileSet := make(TileSet)
+	for name, tile := range system.TileSet {
+		tileSet[name] = tile
+	}
+
+	positions := system.InitialTiles.SortedPositions()
+
+	tileNames := system.TileSet.TileNames()
+	var seed []TASSeedTile
+
+	for _, pos := range positions {
+		tile := system.InitialTiles[pos]
+		name, ok := tileNames[tile]
+
+		if !ok {
+			for i := 0; ; i += 1 {
+				name = "seed_" + strconv.Itoa(i)
+				if _, taken := tileSet[name]; !taken {
+					break
+				}
+			}
+			tileSet[name] = tile
+			tileNames[tile] = name
+		}
+
+		seed = append(seed, TASSeedTile{Tile: name, X: pos[0], Y: pos[1]})
+	}
+
+	return tileSet, seed
+}
+
+// Reads an ISU TAS assembly file and its tile definition file
+func LoadTASSystem(tdpPath string) (system TileSystem, err error) {
+	tdpFile, err := os.Open(tdpPath)
+
+	if err != nil {
+		return system, err
+	}
+	defer tdpFile.Close()
+
+	tdsPath, seed, threshold, err := ReadTDP(tdpFile)
+
+	if err != nil {
+		return system, fmt.Errorf("%s: %v", tdpPath, err)
+	}
+
+	if !filepath.IsAbs(tdsPath) {
+		tdsPath = filepath.Join(filepath.Dir(tdpPath), tdsPath)
+	}
+
+	tdsFile, err := os.Open(tdsPath)
+
+	if err != nil {
+		return system, err
+	}
+	defer tdsFile.Close()
+
+	system.TileSet, system.GlueStrengths, err = ReadTDS(tdsFile)
+
+	if err != nil {
+		return system, fmt.Errorf("%s: %v", tdsPath, err)
+	}
+
+	system.Threshold = threshold
+	system.InitialTiles, err = seedTileMap(system.TileSet, seed)
+
+	if err != nil {
+		return system, fmt.Errorf("%s: %v", tdpPath, err)
+	}
+
+	return system, nil
+}
+
+// Writes the tile system as an ISU TAS assembly file and a tile definition file
+// with the same name and the .tds extension, see namedSeed for initial tiles
+func SaveTASSystem(tdpPath string, system TileSystem) error {
+	if err := system.checkSquareLattice("ISU TAS"); err != nil {
+		return err
+	}
+
+	if err := system.checkNoConcentrations("ISU TAS"); err != nil {
+		return err
+	}
+
+	tileSet, seed := namedSeed(system)
+	tdsPath := strings.TrimSuffix(tdpPath, filepath.Ext(tdpPath)) + ".tds"
+
+	tdsFile, err := os.Create(tdsPath)
+
+	if err != nil {
+		return err
+	}
+
+	if err := WriteTDS(tdsFile, tileSet, system.GlueStrengths); err != nil {
+		tdsFile.Close()
+		return err
+	}
+
+	if err := tdsFile.Close(); err != nil {
+		return err
+	}
+
+	tdpFile, err := os.Create(tdpPath)
+
+	if err != nil {
+		return err
+	}
+
+	if err := WriteTDP(tdpFile, filepath.Base(tdsPath), seed, system.Threshold); err != nil {
+		tdpFile.Close()
+		return err
+	}
+
+	return tdpFile.Close()
+}
+
+type pyTASGlue struct {
+	Label    string `json:"label"`
+	Strength int    `json:"strength"`
+}
+
+type pyTASTile struct {
+	Name  string      `json:"name"`
+	Label string      `json:"label,omitempty"`
+	Color string      `json:"color,omitempty"`
+	Glues SquareGlues `json:"glues"`
+}
+
+type pyTASSystem struct {
+	Temperature int           `json:"temperature"`
+	Glues       []pyTASGlue   `json:"glues"`
+	Tiles       []pyTASTile   `json:"tiles"`
+	Seed        []TASSeedTile `json:"seed"`
+}
+
+func ReadPyTAS(r io.Reader) (system TileSystem, err error) {
+	var pyTAS pyTASSystem
+
+	if err := json.NewDecoder(r).Decode(&pyTAS); err != nil {
+		return system, err
+	}
+
+	system.Threshold = pyTAS.Temperature
+	system.TileSet = make(TileSet)
+
+	for _, glue := range pyTAS.Glues {
+		if glue.Strength == 1 {
+			continue
+		}
+		if system.GlueStrengths == nil {
+			system.GlueStrengths = make(GlueStrengths)
+		}
+		system.GlueStrengths[glue.Label] = glue.Strength
+	}
+
+	for _, tile := range pyTAS.Tiles {
+		if _, ok := system.TileSet[tile.Name]; ok {
+			return system, fmt.Errorf("tile %q is defined twice", tile.Name)
+		}
+		system.TileSet[tile.Name] = tile.Glues
+	}
+
+	system.InitialTiles, err = seedTileMap(system.TileSet, pyTAS.Seed)
+
+	return system, err
+}
+
+// Writes the tile system in the PyTAS format, see namedSeed for initial tiles
+func WritePyTAS(w io.Writer, system TileSystem) error {
+	if err := system.checkSquareLattice("PyTAS"); err != nil {
+		return err
+	}
+
+	if err := system.checkNoConcentrations("PyTAS"); err != nil {
+		return err
+	}
+
+	tileSet, seed := namedSeed(system)
+
+	pyTAS := pyTASSystem{Temperature: system.Threshold, Seed: seed, Glues: []pyTASGlue{}, Tiles: []pyTASTile{}}
+
+	glues := make([]string, 0, len(system.GlueStrengths))
+	for glue := range system.GlueStrengths {
+		glues = append(glues, glue)
+	}
+	sort.Strings(glues)
+
+	for _, glue := range glues {
+		pyTAS.Glues = append(pyTAS.Glues, pyTASGlue{Label: glue, Strength: system.GlueStrengths[glue]})
+	}
+
+	for _, name := range tileSet.SortedNames() {
+		pyTAS.Tiles = append(pyTAS.Tiles, pyTASTile{Name: name, Label: name, Glues: tileSet[name]})
+	}
+
+	b, err := json.MarshalIndent(pyTAS, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,117 @@
+package tamtam
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadPyTAS(t *testing.T, path string) TileSystem {
+	file, err := os.Open(path)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+	defer file.Close()
+
+	system, err := ReadPyTAS(file)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	return system
+}
+
+// Testing that the TAS and PyTAS samples describe the same system and survive a round trip
+func TestTASRoundTrip(t *testing.T) {
+	system, err := LoadTASSystem(filepath.Join("testdata", "sierpinski.tdp"))
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if len(system.TileSet) != 7 || system.Threshold != 2 || len(system.InitialTiles) != 1 {
+		t.Fatalf(`Unexpected system %v`, system)
+	}
+
+	if system.GlueStrengths.Strength("v") != 2 || system.GlueStrengths.Strength("0") != 1 {
+		t.Fatalf(`Unexpected glue strengths %v`, system.GlueStrengths)
+	}
+
+	if pyTASSystem := loadPyTAS(t, filepath.Join("testdata", "sierpinski.pytas")); !system.IsEqualTo(pyTASSystem) {
+		t.Fatalf(`TAS system %v differs from PyTAS system %v`, system, pyTASSystem)
+	}
+
+	tdpPath := filepath.Join(t.TempDir(), "copy.tdp")
+
+	if err := SaveTASSystem(tdpPath, system); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if reloaded, err := LoadTASSystem(tdpPath); err != nil || !system.IsEqualTo(reloaded) {
+		t.Fatalf(`TAS round trip gave %v, %v`, reloaded, err)
+	}
+
+	var buffer bytes.Buffer
+
+	if err := WritePyTAS(&buffer, system); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if reloaded, err := ReadPyTAS(&buffer); err != nil || !system.IsEqualTo(reloaded) {
+		t.Fatalf(`PyTAS round trip gave %v, %v`, reloaded, err)
+	}
+
+	// Growing a few rounds to check the glues of the sample are read the right way round
+	assembly, err := system.NewAssembly()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+	for i := 0; i < 4; i += 1 {
+		if _, err := assembly.GrowSync(true); err != nil {
+			t.Fatalf(`%v`, err)
+		}
+	}
+
+	if name := system.TileSet.TileNames()[assembly.GetTileMap()[Vec2Di{1, 1}]]; name != "xor_11" {
+		t.Fatalf(`Tile at (1, 1) is %q instead of xor_11`, name)
+	}
+}
+
+func TestReadTDSErrors(t *testing.T) {
+	conflicting := `
+TILENAME a
+NORTHBIND 2
+NORTHLABEL x
+CREATE
+TILENAME b
+SOUTHBIND 1
+SOUTHLABEL x
+CREATE
+`
+	if _, _, err := ReadTDS(strings.NewReader(conflicting)); err == nil || !strings.HasPrefix(err.Error(), "line 9:") {
+		t.Fatalf(`Expected conflicting strengths error at line 9, got %v`, err)
+	}
+
+	if _, _, err := ReadTDS(strings.NewReader("TILENAME a\nNORTHBIND 1\n")); err == nil {
+		t.Fatalf(`Expected error for a tile without CREATE`)
+	}
+}
+
+func TestReadTDPErrors(t *testing.T) {
+	for _, tdp := range []string{
+		"tiles.tds\n=\n",
+		"tiles.tds\nTEMPERATURE\n",
+		"tiles.tds\nTEMPERATURE=two\n",
+		"tiles.tds\na 1\n",
+		"tiles.tds\na x 1\n",
+	} {
+		if _, _, _, err := ReadTDP(strings.NewReader(tdp)); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+			t.Fatalf(`Expected an error at line 2 for %q, got %v`, tdp, err)
+		}
+	}
+}
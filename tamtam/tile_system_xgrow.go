@@ -0,0 +1,374 @@
+package tamtam
+
+// Reading and writing xgrow tile files (.tiles):
+//
+//	% Sierpinski triangle
+//	tile edges matches {{N E S W}*}
+//	num tile types=3
+//	num binding types=2
+//	tile edges={
+//	{1 2 0 0}[1](red)
+//	{1 0 1 0}
+//	{0 2 0 2}(blue)
+//	}
+//	binding strengths={2 2}
+//	seed=128,128,1
+//	T=2
+//
+// Tiles are named by their 1-based index in the list, glue ids are kept as glue names, glue 0 being
+// the null glue. Stoichiometries are tile concentrations, colors are ignored, as are the parameters
+// tamtam has no use for.
+// The seed tile is placed at the origin, xgrow uses tile 1 when there is no seed parameter.
+// T is the threshold, 2 if not given.
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const XGROW_DEFAULT_SEED_POSITION = 128
+
+type xgrowParser struct {
+	text   string
+	offset int
+}
+
+func (parser *xgrowParser) errorf(offset int, format string, a ...interface{}) error {
+	line := strings.Count(parser.text[:offset], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, a...))
+}
+
+// Reads the value of a parameter, inside braces (which may span several lines) or up to the end of the line
+func (parser *xgrowParser) readValue() (value string, start int, err error) {
+	lineEnd := strings.IndexByte(parser.text[parser.offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(parser.text)
+	} else {
+		lineEnd += parser.offset
+	}
+
+	rest := strings.TrimSpace(parser.text[parser.offset:lineEnd])
+	start = parser.offset + strings.Index(parser.text[parser.offset:], rest)
+
+	if !strings.HasPrefix(rest, "{") {
+		parser.offset = lineEnd
+		return rest, start, nil
+	}
+
+	depth := 0
+	for i := start; i < len(parser.text); i += 1 {
+		switch parser.text[i] {
+		case '{':
+			depth += 1
+		case '}':
+			depth -= 1
+			if depth == 0 {
+				parser.offset = i + 1
+				return parser.text[start+1 : i], start + 1, nil
+			}
+		}
+	}
+
+	return "", start, parser.errorf(start, "unbalanced braces")
+}
+
+// Parses the tile list {N E S W}[stoichiometry](color) ..., stoichiometries are 1 when not given
+func (parser *xgrowParser) parseTiles(value string, start int) (tiles []SquareGlues, stoichiometries []float64, err error) {
+	i := 0
+	skipSpaces := func() {
+		for i < len(value) && strings.IndexByte(" \t\r\n", value[i]) >= 0 {
+			i += 1
+		}
+	}
+
+	for skipSpaces(); i < len(value); skipSpaces() {
+		if value[i] != '{' {
+			return nil, nil, parser.errorf(start+i, "expected '{' at the start of a tile")
+		}
+
+		end := strings.IndexByte(value[i:], '}')
+		if end < 0 {
+			return nil, nil, parser.errorf(start+i, "unterminated tile")
+		}
+
+		fields := strings.Fields(value[i+1 : i+end])
+		if len(fields) != 4 {
+			return nil, nil, parser.errorf(start+i, "expected 4 glues, got %d", len(fields))
+		}
+
+		var tile SquareGlues
+		for side, field := range fields {
+			glue, err := strconv.Atoi(field)
+			if err != nil || glue < 0 {
+				return nil, nil, parser.errorf(start+i, "invalid glue %q", field)
+			}
+			if glue != 0 {
+				tile[side] = strconv.Itoa(glue)
+			}
+		}
+		tiles = append(tiles, tile)
+		stoichiometries = append(stoichiometries, 1)
+		i += end + 1
+
+		// Optional stoichiometry and color
+		for _, delimiters := range []string{"[]", "()"} {
+			skipSpaces()
+			if i < len(value) && value[i] == delimiters[0] {
+				end := strings.IndexByte(value[i:], delimiters[1])
+				if end < 0 {
+					return nil, nil, parser.errorf(start+i, "missing '%c'", delimiters[1])
+				}
+				if delimiters == "[]" {
+					field := strings.TrimSpace(value[i+1 : i+end])
+					stoichiometry, err := strconv.ParseFloat(field, 64)
+					if err != nil || !(stoichiometry > 0) || math.IsInf(stoichiometry, 1) {
+						return nil, nil, parser.errorf(start+i, "invalid stoichiometry %q", field)
+					}
+					stoichiometries[len(stoichiometries)-1] = stoichiometry
+				}
+				i += end + 1
+			}
+		}
+	}
+
+	return tiles, stoichiometries, nil
+}
+
+func ReadXgrowTiles(r io.Reader) (system TileSystem, err error) {
+	b, err := ioutil.ReadAll(r)
+
+	if err != nil {
+		return system, err
+	}
+
+	// Removing comments, keeping line breaks for error positions
+	lines := strings.Split(string(b), "\n")
+	for i, line := range lines {
+		if comment := strings.IndexByte(line, '%'); comment >= 0 {
+			lines[i] = line[:comment]
+		}
+	}
+
+	parser := xgrowParser{text: strings.Join(lines, "\n")}
+
+	var tiles []SquareGlues
+	var stoichiometries []float64
+	var strengths []int
+	numTileTypes, numBindingTypes := -1, -1
+	seedTile := 1
+	seedTileOffset := -1
+	system.Threshold = 2
+
+	for parser.offset < len(parser.text) {
+		lineEnd := strings.IndexByte(parser.text[parser.offset:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(parser.text) - parser.offset
+		}
+
+		// Lines without parameter such as "tile edges matches {{N E S W}*}"
+		equal := strings.IndexByte(parser.text[parser.offset:parser.offset+lineEnd], '=')
+		if equal < 0 {
+			parser.offset += lineEnd + 1
+			continue
+		}
+
+		key := strings.Join(strings.Fields(parser.text[parser.offset:parser.offset+equal]), " ")
+		parser.offset += equal + 1
+
+		value, start, err := parser.readValue()
+
+		if err != nil {
+			return system, err
+		}
+
+		switch key {
+		case "tile edges":
+			if tiles, stoichiometries, err = parser.parseTiles(value, start); err != nil {
+				return system, err
+			}
+		case "binding strengths":
+			strengths = nil
+			for _, field := range strings.Fields(value) {
+				strength, err := strconv.ParseFloat(field, 64)
+				if err != nil || strength != math.Trunc(strength) {
+					return system, parser.errorf(start, "invalid binding strength %q, strengths must be integers", field)
+				}
+				strengths = append(strengths, int(strength))
+			}
+		case "num tile types", "num binding types", "T":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return system, parser.errorf(start, "invalid %s %q", key, value)
+			}
+			switch key {
+			case "num tile types":
+				numTileTypes = n
+			case "num binding types":
+				numBindingTypes = n
+			default:
+				system.Threshold = n
+			}
+		case "seed":
+			fields := strings.Split(value, ",")
+			if len(fields) != 3 {
+				return system, parser.errorf(start, "expected seed=i,j,n")
+			}
+			if seedTile, err = strconv.Atoi(strings.TrimSpace(fields[2])); err != nil {
+				return system, parser.errorf(start, "invalid seed tile %q", fields[2])
+			}
+			seedTileOffset = start
+		}
+	}
+
+	if numTileTypes >= 0 && numTileTypes != len(tiles) {
+		return system, fmt.Errorf("%d tile types announced, %d given", numTileTypes, len(tiles))
+	}
+
+	if numBindingTypes >= 0 && strengths != nil && numBindingTypes != len(strengths) {
+		return system, fmt.Errorf("%d binding types announced, %d strengths given", numBindingTypes, len(strengths))
+	}
+
+	system.TileSet = make(TileSet)
+	for i, tile := range tiles {
+		system.TileSet[strconv.Itoa(i+1)] = tile
+
+		if stoichiometries[i] == 1 {
+			continue
+		}
+		if system.TileConcentrations == nil {
+			system.TileConcentrations = make(TileConcentrations)
+		}
+		system.TileConcentrations[strconv.Itoa(i+1)] = stoichiometries[i]
+	}
+
+	for i, strength := range strengths {
+		if strength == 1 {
+			continue
+		}
+		if system.GlueStrengths == nil {
+			system.GlueStrengths = make(GlueStrengths)
+		}
+		system.GlueStrengths[strconv.Itoa(i+1)] = strength
+	}
+
+	system.InitialTiles = make(TileMap)
+
+	if len(tiles) > 0 {
+		if seedTile < 1 || seedTile > len(tiles) {
+			if seedTileOffset < 0 {
+				seedTileOffset = 0
+			}
+			return system, parser.errorf(seedTileOffset, "seed tile %d does not exist", seedTile)
+		}
+		system.InitialTiles[Vec2Di{0, 0}] = tiles[seedTile-1]
+	}
+
+	return system, nil
+}
+
+// Numbers glues for xgrow: glues that already are positive integers are kept,
+// otherwise glues are numbered in their order of appearance in the tiles
+func xgrowGlueIds(tileSet TileSet, names []string) (ids map[string]int, numBindingTypes int) {
+	ids = make(map[string]int)
+	keep := true
+
+	for _, name := range names {
+		for _, glue := range tileSet[name] {
+			if glue == NULL_GLUE {
+				continue
+			}
+			id, err := strconv.Atoi(glue)
+			if err != nil || id <= 0 || strconv.Itoa(id) != glue {
+				keep = false
+			}
+			ids[glue] = id
+		}
+	}
+
+	if keep {
+		for _, id := range ids {
+			if id > numBindingTypes {
+				numBindingTypes = id
+			}
+		}
+		return ids, numBindingTypes
+	}
+
+	ids = make(map[string]int)
+	for _, name := range names {
+		for _, glue := range tileSet[name] {
+			if _, ok := ids[glue]; glue != NULL_GLUE && !ok {
+				numBindingTypes += 1
+				ids[glue] = numBindingTypes
+			}
+		}
+	}
+
+	return ids, numBindingTypes
+}
+
+// Writes the tile system as an xgrow tile file, tiles in the order of SortedNames with their
+// names as comments. The initial tiles must be a single tile of the tile set, or no tile.
+func WriteXgrowTiles(w io.Writer, system TileSystem) error {
+	if err := system.checkSquareLattice("xgrow"); err != nil {
+		return err
+	}
+
+	names := system.TileSet.SortedNames()
+	seedTile := 0
+
+	if len(system.InitialTiles) > 1 {
+		return errors.New("xgrow only supports a seed made of a single tile")
+	}
+
+	for _, tile := range system.InitialTiles {
+		for i, name := range names {
+			if system.TileSet[name] == tile {
+				seedTile = i + 1
+				break
+			}
+		}
+		if seedTile == 0 {
+			return errors.New("the seed tile is not in the tile set")
+		}
+	}
+
+	ids, numBindingTypes := xgrowGlueIds(system.TileSet, names)
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "tile edges matches {{N E S W}*}")
+	fmt.Fprintf(bw, "num tile types=%d\n", len(names))
+	fmt.Fprintf(bw, "num binding types=%d\n", numBindingTypes)
+	fmt.Fprintln(bw, "tile edges={")
+	for _, name := range names {
+		tile := system.TileSet[name]
+		stoichiometry := ""
+		if concentration := system.TileConcentrations.Concentration(name); concentration != 1 {
+			stoichiometry = "[" + strconv.FormatFloat(concentration, 'g', -1, 64) + "]"
+		}
+		fmt.Fprintf(bw, "{%d %d %d %d}%s %% %s\n", ids[tile[0]], ids[tile[1]], ids[tile[2]], ids[tile[3]], stoichiometry, name)
+	}
+	fmt.Fprintln(bw, "}")
+
+	strengths := make([]string, numBindingTypes)
+	for i := range strengths {
+		strengths[i] = "1"
+	}
+	for glue, id := range ids {
+		strengths[id-1] = strconv.Itoa(system.GlueStrengths.Strength(glue))
+	}
+	fmt.Fprintf(bw, "binding strengths={%s}\n", strings.Join(strengths, " "))
+
+	if seedTile > 0 {
+		fmt.Fprintf(bw, "seed=%d,%d,%d\n", XGROW_DEFAULT_SEED_POSITION, XGROW_DEFAULT_SEED_POSITION, seedTile)
+	}
+	fmt.Fprintf(bw, "T=%d\n", system.Threshold)
+
+	return bw.Flush()
+}
@@ -0,0 +1,88 @@
+package tamtam
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestXgrowRoundTrip(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "sierpinski.tiles"))
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+	defer file.Close()
+
+	system, err := ReadXgrowTiles(file)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if len(system.TileSet) != 7 || system.Threshold != 2 || system.InitialTiles[Vec2Di{0, 0}] != system.TileSet["1"] {
+		t.Fatalf(`Unexpected system %v`, system)
+	}
+
+	if system.GlueStrengths.Strength("4") != 2 || system.GlueStrengths.Strength("1") != 1 {
+		t.Fatalf(`Unexpected glue strengths %v`, system.GlueStrengths)
+	}
+
+	assembly, err := system.NewAssembly()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	for i := 0; i < 4; i += 1 {
+		if _, err := assembly.GrowSync(true); err != nil {
+			t.Fatalf(`%v`, err)
+		}
+	}
+
+	if name := system.TileSet.TileNames()[assembly.GetTileMap()[Vec2Di{1, 1}]]; name != "7" {
+		t.Fatalf(`Tile at (1, 1) is %q instead of 7`, name)
+	}
+
+	var buffer bytes.Buffer
+
+	if err := WriteXgrowTiles(&buffer, system); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if reloaded, err := ReadXgrowTiles(&buffer); err != nil || !system.IsEqualTo(reloaded) {
+		t.Fatalf(`Round trip gave %v, %v`, reloaded, err)
+	}
+
+	// Formats without geometries refuse systems that do not grow on the plane
+	system.Geometry = &Geometry{Kind: GEOMETRY_BOUNDED, UpperRight: Vec2Di{9, 9}}
+
+	for format, write := range map[string]func(io.Writer, TileSystem) error{
+		"xgrow":  WriteXgrowTiles,
+		"DSL":    WriteTileSystemDSL,
+		"PyTAS":  WritePyTAS,
+		"binary": WriteTileSystemBinary,
+	} {
+		if err := write(&bytes.Buffer{}, system); err == nil {
+			t.Fatalf(`The %s writer accepted a bounded geometry`, format)
+		}
+	}
+}
+
+func TestReadXgrowTilesErrors(t *testing.T) {
+	for _, tiles := range []string{
+		"tile edges={\n{1 2 0}\n}",
+		"tile edges={\n{1 2 0 0}\n",
+		"tile edges={{1 2 0 0}}\nbinding strengths={0.5 1}",
+		"num tile types=2\ntile edges={{1 2 0 0}}",
+		"tile edges={{1 2 0 0}}\nseed=1,1,2",
+		"tile edges={{1 2 0 0}[0]}",
+	} {
+		if _, err := ReadXgrowTiles(strings.NewReader(tiles)); err == nil {
+			t.Fatalf(`Expected an error for %q`, tiles)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package tamtam
+
+// x, y, z
+type Vec3Di [3]int
+
+var Up Vec3Di = Vec3Di{0, 0, 1}
+var Down Vec3Di = Vec3Di{0, 0, -1}
+
+// Directions of the sides of cubes: north, east, south, west, up, down
+var CubeDirections = []Vec3Di{{0, 1, 0}, {1, 0, 0}, {0, -1, 0}, {-1, 0, 0}, Up, Down}
+
+func (a Vec3Di) Add(b Vec3Di) Vec3Di {
+	return Vec3Di{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
+
+func (a Vec3Di) Neighbors() (neighbors [6]Vec3Di) {
+	for i, direction := range CubeDirections {
+		neighbors[i] = a.Add(direction)
+	}
+	return neighbors
+}
+
+// Side of the neighboring cube which touches the given side
+func cubeOpposite(side int) int {
+	if side >= 4 {
+		return 9 - side
+	}
+	return (side + 2) % 4
+}
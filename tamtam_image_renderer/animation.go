@@ -0,0 +1,232 @@
+package tamtam_image_renderer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	tt "tamtam/tamtam"
+)
+
+// Not premultiplied, drawn over the tiles
+var HIGHLIGHT_COLOR = color.NRGBA{255, 0, 0, 96}
+
+type AnimationParameters struct {
+	RenderParameters
+	// One frame per synchronous growth round if 0, otherwise one frame every AsyncStepsPerFrame asynchronous steps
+	AsyncStepsPerFrame int `json:"async_steps_per_frame"`
+	// Growth stops after that many frames (the initial assembly not counting), no limit if 0
+	MaxFrames int  `json:"max_frames"`
+	Directed  bool `json:"directed"`
+	// Delay between GIF frames in 100ths of a second
+	FrameDelay int `json:"frame_delay"`
+	// Tiles added since the previous frame are covered with that color
+	HighlightNewTiles bool `json:"highlight_new_tiles"`
+}
+
+func NewAnimationParameters() (toReturn AnimationParameters) {
+	toReturn.RenderParameters = NewRenderParameters()
+	toReturn.Directed = true
+	toReturn.FrameDelay = 20
+	toReturn.HighlightNewTiles = true
+	return toReturn
+}
+
+// Grows the assembly until it is terminal (or MaxFrames is reached) and
+// returns one picture of the assembly per frame, the first one being the
+// assembly before growth. All frames have the size of the region holding
+// every tile placed, detached tiles included. Returns an error if the tiles
+// of the assembly are not square or if the region of the parameters is empty.
+func RecordGrowth(assembly *tt.TileAssembly, params AnimationParameters) ([]*image.RGBA, error) {
+	if err := CheckLattice(assembly.GetLattice()); err != nil {
+		return nil, err
+	}
+
+	if err := params.RenderParameters.Validate(); err != nil {
+		return nil, err
+	}
+
+	initialTiles := make(tt.TileMap)
+	placedTiles := make(tt.TileMap)
+	for pos, tile := range assembly.GetTileMap() {
+		initialTiles[pos] = tile
+		placedTiles[pos] = tile
+	}
+
+	changes := tt.NewChangeLog()
+	assembly.AddObserver(changes)
+	defer assembly.RemoveObserver(changes)
+
+	// Growing first, pictures are drawn once the final size of the assembly is known
+	var addedPerFrame [][]tt.PosAndTile
+	var removedPerFrame [][]tt.PosAndTile
+
+	for params.MaxFrames <= 0 || len(addedPerFrame) < params.MaxFrames {
+		var didGrow bool
+		var err error
+
+		if params.AsyncStepsPerFrame <= 0 {
+			didGrow, err = assembly.GrowSync(params.Directed)
+		} else {
+			for step := 0; step < params.AsyncStepsPerFrame; step += 1 {
+				var didStepGrow bool
+				didStepGrow, err = assembly.GrowAsync(params.Directed)
+				didGrow = didGrow || didStepGrow
+				if err != nil || !didStepGrow {
+					break
+				}
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !didGrow {
+			break
+		}
+
+		added := changes.GetAddedTiles()
+		for _, posAndTile := range added {
+			placedTiles[posAndTile.Pos] = posAndTile.Tile
+		}
+
+		addedPerFrame = append(addedPerFrame, added)
+		removedPerFrame = append(removedPerFrame, changes.GetRemovedTiles())
+		changes.FlushAddedTiles()
+		changes.FlushRemovedTiles()
+	}
+
+	renderParams := params.RenderParameters
+	region := renderParams.regionOf(placedTiles)
+	renderParams.Region = &region
+
+	// Drawing each frame over the previous one, the tiles that detached are cleared first
+	// as their position can be filled again in the same frame
+	canvas, err := RenderTileMap(assembly.GetOrientedTileSet(), initialTiles, renderParams)
+
+	if err != nil {
+		return nil, err
+	}
+
+	frames := []*image.RGBA{cloneImage(canvas)}
+
+	for i, added := range addedPerFrame {
+		for _, posAndTile := range removedPerFrame[i] {
+			fillRect(canvas, tileRect(posAndTile.Pos, region, renderParams.TileSize), BACKGROUND_COLOR)
+		}
+
+		addedTiles := make(tt.TileMap)
+		for _, posAndTile := range added {
+			addedTiles[posAndTile.Pos] = posAndTile.Tile
+		}
+
+		drawTileMap(canvas, assembly.GetOrientedTileSet(), addedTiles, renderParams)
+		frame := cloneImage(canvas)
+
+		if params.HighlightNewTiles {
+			for pos := range addedTiles {
+				rect := tileRect(pos, region, renderParams.TileSize)
+				draw.Draw(frame, rect, &image.Uniform{HIGHLIGHT_COLOR}, image.Point{}, draw.Over)
+			}
+		}
+
+		frames = append(frames, frame)
+	}
+
+	return frames, nil
+}
+
+func cloneImage(img *image.RGBA) *image.RGBA {
+	clone := image.NewRGBA(img.Rect)
+	copy(clone.Pix, img.Pix)
+	return clone
+}
+
+// Builds a palette with the colors of the frames, falling back to a generic
+// palette when there are more colors than a GIF allows
+func framesPalette(frames []*image.RGBA) color.Palette {
+	seen := make(map[color.RGBA]bool)
+	var framesColors color.Palette
+
+	for _, frame := range frames {
+		for i := 0; i < len(frame.Pix); i += 4 {
+			c := color.RGBA{frame.Pix[i], frame.Pix[i+1], frame.Pix[i+2], frame.Pix[i+3]}
+			if seen[c] {
+				continue
+			}
+			seen[c] = true
+			framesColors = append(framesColors, c)
+			if len(framesColors) > 256 {
+				return palette.Plan9
+			}
+		}
+	}
+
+	return framesColors
+}
+
+func WriteGIF(w io.Writer, frames []*image.RGBA, frameDelay int) error {
+	framesColors := framesPalette(frames)
+	animation := gif.GIF{}
+
+	for _, frame := range frames {
+		paletted := image.NewPaletted(frame.Rect, framesColors)
+		draw.Draw(paletted, frame.Rect, frame, image.Point{}, draw.Src)
+		animation.Image = append(animation.Image, paletted)
+		animation.Delay = append(animation.Delay, frameDelay)
+	}
+
+	return gif.EncodeAll(w, &animation)
+}
+
+func SaveGIF(path string, frames []*image.RGBA, frameDelay int) error {
+	file, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	err = WriteGIF(file, frames, frameDelay)
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Writes the frames as numbered PNG files frame_0000.png, frame_0001.png, ... in the directory
+func SaveFrames(directory string, frames []*image.RGBA) error {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return err
+	}
+
+	for i, frame := range frames {
+		file, err := os.Create(filepath.Join(directory, fmt.Sprintf("frame_%04d.png", i)))
+
+		if err != nil {
+			return err
+		}
+
+		err = png.Encode(file, frame)
+
+		if err != nil {
+			file.Close()
+			return err
+		}
+
+		if err = file.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package tamtam_image_renderer
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	tt "tamtam/tamtam"
+	"testing"
+)
+
+// Whether the color is the tile color covered by the highlight, up to rounding
+func isHighlighted(c color.RGBA, tileColor color.RGBA) bool {
+	near := func(got uint8, want int) bool {
+		return int(got) >= want-1 && int(got) <= want+1
+	}
+	alpha := int(HIGHLIGHT_COLOR.A)
+	return near(c.R, (int(HIGHLIGHT_COLOR.R)*alpha+int(tileColor.R)*(255-alpha))/255) &&
+		near(c.G, (int(HIGHLIGHT_COLOR.G)*alpha+int(tileColor.G)*(255-alpha))/255) &&
+		near(c.B, (int(HIGHLIGHT_COLOR.B)*alpha+int(tileColor.B)*(255-alpha))/255) && c.A == 255
+}
+
+// The tile added by the first growth round is covered with the highlight in the second frame only
+func TestRecordGrowthHighlight(t *testing.T) {
+	assembly := newCrtAssembly(t, 3)
+	params := NewAnimationParameters()
+	params.ShowGrid = false
+
+	frames, err := RecordGrowth(&assembly, params)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	// Rounds grow anti-diagonals of the 3 by 3 square
+	if len(frames) != 6 {
+		t.Fatalf(`%d frames`, len(frames))
+	}
+
+	rect := tileRect(tt.Vec2Di{0, 0}, Region{LowerLeft: tt.Vec2Di{-1, -1}, UpperRight: tt.Vec2Di{2, 2}}, params.TileSize)
+	center := image.Point{rect.Min.X + rect.Dx()/2, rect.Min.Y + rect.Dy()/2}
+	tileColor := frames[len(frames)-1].RGBAAt(center.X, center.Y)
+
+	if frames[0].RGBAAt(center.X, center.Y) != BACKGROUND_COLOR {
+		t.Fatalf(`The first frame is not the seed alone`)
+	}
+
+	if c := frames[1].RGBAAt(center.X, center.Y); !isHighlighted(c, tileColor) {
+		t.Fatalf(`Highlighted tile color %v, expected %v covered by %v`, c, tileColor, HIGHLIGHT_COLOR)
+	}
+
+	if c := frames[2].RGBAAt(center.X, center.Y); c != tileColor {
+		t.Fatalf(`The tile is still highlighted in the third frame, %v`, c)
+	}
+}
+
+// A tile attached by the first growth round is repelled by a tile of the second round and
+// detaches: it is gone from the third frame
+func TestRecordGrowthDetachment(t *testing.T) {
+	system := tt.TileSystem{
+		TileSet: tt.TileSet{
+			"broken":  tt.SquareGlues{"n", tt.NULL_GLUE, tt.NULL_GLUE, "a"},
+			"column":  tt.SquareGlues{tt.NULL_GLUE, "x", "u", tt.NULL_GLUE},
+			"breaker": tt.SquareGlues{tt.NULL_GLUE, tt.NULL_GLUE, "n", "x"},
+		},
+		GlueStrengths: tt.GlueStrengths{"x": 2, "n": -1},
+		InitialTiles:  tt.TileMap{tt.Vec2Di{0, 0}: tt.SquareGlues{"u", "a", tt.NULL_GLUE, tt.NULL_GLUE}},
+		Threshold:     1,
+		GrowthModel:   tt.GrowthModel{Detachment: tt.DETACHMENT_UNSTABLE},
+	}
+	assembly, err := system.NewAssembly()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	params := NewAnimationParameters()
+	params.HighlightNewTiles = false
+	frames, err := RecordGrowth(&assembly, params)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if len(frames) != 3 {
+		t.Fatalf(`%d frames`, len(frames))
+	}
+
+	rect := tileRect(tt.Vec2Di{1, 0}, Region{LowerLeft: tt.Vec2Di{0, 0}, UpperRight: tt.Vec2Di{1, 1}}, params.TileSize)
+
+	if frames[1].RGBAAt(rect.Min.X+rect.Dx()/4, rect.Min.Y+rect.Dy()/2) == BACKGROUND_COLOR {
+		t.Fatalf(`The tile is missing from the second frame`)
+	}
+
+	for y := rect.Min.Y; y < rect.Max.Y; y += 1 {
+		for x := rect.Min.X; x < rect.Max.X; x += 1 {
+			if c := frames[2].RGBAAt(x, y); c != BACKGROUND_COLOR {
+				t.Fatalf(`The detached tile is still drawn at (%d, %d) in the third frame, %v`, x, y, c)
+			}
+		}
+	}
+}
+
+func recordCrtGrowth(t *testing.T) []*image.RGBA {
+	assembly := newCrtAssembly(t, 3)
+	frames, err := RecordGrowth(&assembly, NewAnimationParameters())
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	return frames
+}
+
+// Decoded GIF frames have the colors of the recorded frames
+func TestWriteGIF(t *testing.T) {
+	frames := recordCrtGrowth(t)
+
+	var b bytes.Buffer
+
+	if err := WriteGIF(&b, frames, 20); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	animation, err := gif.DecodeAll(&b)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if len(animation.Image) != len(frames) || animation.Delay[0] != 20 {
+		t.Fatalf(`%d GIF frames for %d recorded frames`, len(animation.Image), len(frames))
+	}
+
+	for i, frame := range frames {
+		for y := frame.Rect.Min.Y; y < frame.Rect.Max.Y; y += 1 {
+			for x := frame.Rect.Min.X; x < frame.Rect.Max.X; x += 1 {
+				if got := color.RGBAModel.Convert(animation.Image[i].At(x, y)); got != frame.RGBAAt(x, y) {
+					t.Fatalf(`Frame %d at (%d, %d): %v instead of %v`, i, x, y, got, frame.RGBAAt(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestSaveFrames(t *testing.T) {
+	frames := recordCrtGrowth(t)
+	directory := filepath.Join(t.TempDir(), "frames")
+
+	if err := SaveFrames(directory, frames); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	files, err := ioutil.ReadDir(directory)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if len(files) != len(frames) || files[0].Name() != "frame_0000.png" {
+		t.Fatalf(`%d files for %d frames`, len(files), len(frames))
+	}
+
+	file, err := os.Open(filepath.Join(directory, fmt.Sprintf("frame_%04d.png", len(frames)-1)))
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+	defer file.Close()
+
+	last, err := png.Decode(file)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !reflect.DeepEqual(last.(*image.RGBA).Pix, frames[len(frames)-1].Pix) {
+		t.Fatalf(`The last PNG differs from the last frame`)
+	}
+}
@@ -0,0 +1,119 @@
+package tamtam_image_renderer
+
+import (
+	"image"
+	"image/color"
+	"strings"
+)
+
+const GLYPH_WIDTH = 3
+const GLYPH_HEIGHT = 5
+
+// Tiny bitmap font so that we do not depend on anything else than the standard library.
+// Each glyph is GLYPH_HEIGHT rows of GLYPH_WIDTH pixels, '#' being a lit pixel.
+// Lower case letters are drawn with the upper case glyphs.
+var glyphs = map[rune][GLYPH_HEIGHT]string{
+	'0':  {"###", "#.#", "#.#", "#.#", "###"},
+	'1':  {".#.", "##.", ".#.", ".#.", "###"},
+	'2':  {"###", "..#", "###", "#..", "###"},
+	'3':  {"###", "..#", ".##", "..#", "###"},
+	'4':  {"#.#", "#.#", "###", "..#", "..#"},
+	'5':  {"###", "#..", "###", "..#", "###"},
+	'6':  {"###", "#..", "###", "#.#", "###"},
+	'7':  {"###", "..#", ".#.", ".#.", ".#."},
+	'8':  {"###", "#.#", "###", "#.#", "###"},
+	'9':  {"###", "#.#", "###", "..#", "###"},
+	'A':  {".#.", "#.#", "###", "#.#", "#.#"},
+	'B':  {"##.", "#.#", "##.", "#.#", "##."},
+	'C':  {".##", "#..", "#..", "#..", ".##"},
+	'D':  {"##.", "#.#", "#.#", "#.#", "##."},
+	'E':  {"###", "#..", "##.", "#..", "###"},
+	'F':  {"###", "#..", "##.", "#..", "#.."},
+	'G':  {".##", "#..", "#.#", "#.#", ".##"},
+	'H':  {"#.#", "#.#", "###", "#.#", "#.#"},
+	'I':  {"###", ".#.", ".#.", ".#.", "###"},
+	'J':  {"..#", "..#", "..#", "#.#", ".#."},
+	'K':  {"#.#", "#.#", "##.", "#.#", "#.#"},
+	'L':  {"#..", "#..", "#..", "#..", "###"},
+	'M':  {"#.#", "###", "###", "#.#", "#.#"},
+	'N':  {"##.", "#.#", "#.#", "#.#", "#.#"},
+	'O':  {".#.", "#.#", "#.#", "#.#", ".#."},
+	'P':  {"##.", "#.#", "##.", "#..", "#.."},
+	'Q':  {".#.", "#.#", "#.#", "##.", ".##"},
+	'R':  {"##.", "#.#", "##.", "#.#", "#.#"},
+	'S':  {".##", "#..", ".#.", "..#", "##."},
+	'T':  {"###", ".#.", ".#.", ".#.", ".#."},
+	'U':  {"#.#", "#.#", "#.#", "#.#", "###"},
+	'V':  {"#.#", "#.#", "#.#", "#.#", ".#."},
+	'W':  {"#.#", "#.#", "###", "###", "#.#"},
+	'X':  {"#.#", "#.#", ".#.", "#.#", "#.#"},
+	'Y':  {"#.#", "#.#", ".#.", ".#.", ".#."},
+	'Z':  {"###", "..#", ".#.", "#..", "###"},
+	' ':  {"...", "...", "...", "...", "..."},
+	'-':  {"...", "...", "###", "...", "..."},
+	'+':  {"...", ".#.", "###", ".#.", "..."},
+	'=':  {"...", "###", "...", "###", "..."},
+	'_':  {"...", "...", "...", "...", "###"},
+	'.':  {"...", "...", "...", "...", ".#."},
+	',':  {"...", "...", "...", ".#.", "#.."},
+	':':  {"...", ".#.", "...", ".#.", "..."},
+	'*':  {"#.#", ".#.", "#.#", "...", "..."},
+	'/':  {"..#", "..#", ".#.", "#..", "#.."},
+	'#':  {"#.#", "###", "#.#", "###", "#.#"},
+	'(':  {".#.", "#..", "#..", "#..", ".#."},
+	')':  {".#.", "..#", "..#", "..#", ".#."},
+	'[':  {"##.", "#..", "#..", "#..", "##."},
+	']':  {".##", "..#", "..#", "..#", ".##"},
+	'<':  {"..#", ".#.", "#..", ".#.", "..#"},
+	'>':  {"#..", ".#.", "..#", ".#.", "#.."},
+	'!':  {".#.", ".#.", ".#.", "...", ".#."},
+	'?':  {"##.", "..#", ".#.", "...", ".#."},
+	'\'': {".#.", ".#.", "...", "...", "..."},
+}
+
+// Returns the size in pixels of the text drawn with the given scale
+func textSize(text string, scale int) (width int, height int) {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0, 0
+	}
+	return (n*(GLYPH_WIDTH+1) - 1) * scale, GLYPH_HEIGHT * scale
+}
+
+// Returns the largest scale at which the text fits in a box of the given size, 0 if it does not fit at all
+func fittingScale(text string, boxWidth int, boxHeight int) int {
+	width, height := textSize(text, 1)
+	if width == 0 {
+		return 0
+	}
+	scaleX := boxWidth / width
+	scaleY := boxHeight / height
+	if scaleX < scaleY {
+		return scaleX
+	}
+	return scaleY
+}
+
+// Draws the text centered on the given point
+func drawText(img *image.RGBA, text string, center image.Point, scale int, textColor color.RGBA) {
+	width, height := textSize(text, scale)
+	origin := image.Point{center.X - width/2, center.Y - height/2}
+
+	for i, char := range []rune(strings.ToUpper(text)) {
+		glyph, ok := glyphs[char]
+		if !ok {
+			glyph = glyphs['?']
+		}
+
+		glyphOrigin := origin.Add(image.Point{i * (GLYPH_WIDTH + 1) * scale, 0})
+
+		for row := 0; row < GLYPH_HEIGHT; row += 1 {
+			for column := 0; column < GLYPH_WIDTH; column += 1 {
+				if glyph[row][column] != '#' {
+					continue
+				}
+				fillRect(img, image.Rect(glyphOrigin.X+column*scale, glyphOrigin.Y+row*scale, glyphOrigin.X+(column+1)*scale, glyphOrigin.Y+(row+1)*scale), textColor)
+			}
+		}
+	}
+}
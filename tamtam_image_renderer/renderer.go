@@ -0,0 +1,336 @@
+// Headless rendering of assemblies to in-memory images, only relying on the standard library.
+// Tiles are drawn the same way as in the SDL2 renderer: four triangles colored by their glue,
+// a grid made of the outline and diagonals of each tile and the tile and glue names. Only
+// square tiles are drawn, assemblies on other lattices are rejected.
+package tamtam_image_renderer
+
+import (
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"strconv"
+	tt "tamtam/tamtam"
+)
+
+const TILE_SIZE = 32
+
+var BACKGROUND_COLOR = color.RGBA{0.4 * 255, 0.4 * 255, 0.4 * 255, 255}
+var COLOR_WHEEL = []color.RGBA{{229, 198, 146, 255}, {20, 196, 52, 255}, {227, 121, 151, 255}}
+var GRID_COLOR = color.RGBA{0, 0, 0, 255}
+var TILE_NAME_COLOR = color.RGBA{0, 0, 0, 255}
+var GLUE_NAME_COLOR = color.RGBA{0, 101, 255, 255}
+var ORIENTATION_COLOR = color.RGBA{0, 0, 0, 255}
+
+// Rectangle of assembly positions, both corners are included
+type Region struct {
+	LowerLeft  tt.Vec2Di `json:"lower_left"`
+	UpperRight tt.Vec2Di `json:"upper_right"`
+}
+
+type RenderParameters struct {
+	// Part of the plane to render, the bounding box of the tiles if nil
+	Region        *Region `json:"region"`
+	TileSize      int     `json:"tile_size"`
+	ShowTiles     bool    `json:"show_tiles"`
+	ShowGrid      bool    `json:"show_grid"`
+	ShowTilesText bool    `json:"show_tiles_text"`
+}
+
+// Returns an error if the region holds no position, its upper right corner being to the left
+// of or below its lower left corner
+func (region Region) Validate() error {
+	if region.UpperRight[0] < region.LowerLeft[0] || region.UpperRight[1] < region.LowerLeft[1] {
+		return fmt.Errorf("the region from %v to %v is empty", region.LowerLeft, region.UpperRight)
+	}
+	return nil
+}
+
+func (params RenderParameters) Validate() error {
+	if params.Region != nil {
+		return params.Region.Validate()
+	}
+	return nil
+}
+
+func NewRenderParameters() (toReturn RenderParameters) {
+	toReturn.TileSize = TILE_SIZE
+	toReturn.ShowTiles = true
+	toReturn.ShowGrid = true
+	return toReturn
+}
+
+// Color of a glue: integer glues use the same color wheel as the SDL2 renderer
+// (and the background color when out of the wheel), other glues get a color
+// derived from their name
+func GlueColor(glue string) color.RGBA {
+	if glueInt, err := strconv.Atoi(glue); err == nil {
+		if glueInt >= 0 && glueInt < len(COLOR_WHEEL) {
+			return COLOR_WHEEL[glueInt]
+		}
+		return BACKGROUND_COLOR
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(glue))
+	sum := hash.Sum32()
+
+	// Keeping colors light enough for the text to be readable
+	return color.RGBA{uint8(96 + sum%160), uint8(96 + (sum>>8)%160), uint8(96 + (sum>>16)%160), 255}
+}
+
+// Returns an error for the lattices whose tiles cannot be drawn, all but the square one
+func CheckLattice(lattice tt.Lattice) error {
+	if lattice != tt.LATTICE_SQUARE {
+		return fmt.Errorf("only square tiles can be rendered, not %s ones", lattice)
+	}
+	return nil
+}
+
+// Returns the region to render given the parameters and the tiles
+func (params RenderParameters) regionOf(tileMap tt.TileMap) Region {
+	if params.Region != nil {
+		return *params.Region
+	}
+	lowerLeft, upperRight := tileMap.BoundingBox()
+	return Region{LowerLeft: lowerLeft, UpperRight: upperRight}
+}
+
+// Returns the rectangle of pixels covered by a tile, going north is going up in the image
+func tileRect(tilePos tt.Vec2Di, region Region, tileSize int) image.Rectangle {
+	x := (tilePos[0] - region.LowerLeft[0]) * tileSize
+	y := (region.UpperRight[1] - tilePos[1]) * tileSize
+	return image.Rect(x, y, x+tileSize, y+tileSize)
+}
+
+func fillRect(img *image.RGBA, rect image.Rectangle, fillColor color.RGBA) {
+	draw.Draw(img, rect, &image.Uniform{fillColor}, image.Point{}, draw.Src)
+}
+
+// Bresenham's line between the two points (both included)
+func drawLine(img *image.RGBA, from image.Point, to image.Point, lineColor color.RGBA) {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	stepX, stepY := 1, 1
+	if dx < 0 {
+		dx, stepX = -dx, -1
+	}
+	if dy < 0 {
+		dy, stepY = -dy, -1
+	}
+
+	err := dx - dy
+	x, y := from.X, from.Y
+	for {
+		if (image.Point{x, y}).In(img.Rect) {
+			img.SetRGBA(x, y, lineColor)
+		}
+		if x == to.X && y == to.Y {
+			return
+		}
+		e2 := 2 * err
+		if e2 > -dy {
+			err -= dy
+			x += stepX
+		}
+		if e2 < dx {
+			err += dx
+			y += stepY
+		}
+	}
+}
+
+// Side of the tile (North, East, South, West) the pixel at (u, v) belongs to,
+// u and v being relative coordinates inside the tile going right and down
+func sideOfPixel(u float64, v float64) int {
+	switch {
+	case v <= u && v <= 1-u:
+		return 0
+	case v >= u && v >= 1-u:
+		return 2
+	case u <= v && u <= 1-v:
+		return 3
+	}
+	return 1
+}
+
+// Rendering the four glue triangles of the tile
+func renderTile(img *image.RGBA, tile tt.SquareGlues, rect image.Rectangle) {
+	size := float64(rect.Dx())
+	for y := rect.Min.Y; y < rect.Max.Y; y += 1 {
+		for x := rect.Min.X; x < rect.Max.X; x += 1 {
+			if !(image.Point{x, y}).In(img.Rect) {
+				continue
+			}
+
+			glue := tile[sideOfPixel((float64(x-rect.Min.X)+0.5)/size, (float64(y-rect.Min.Y)+0.5)/size)]
+			if glue == tt.NULL_GLUE {
+				continue
+			}
+			img.SetRGBA(x, y, GlueColor(glue))
+		}
+	}
+}
+
+// Side of the tile where the north side of its tile type is, ok is false for tiles
+// in their original orientation (see tt.OrientedName)
+func orientationMarkerSide(tileName string) (side int, ok bool) {
+	_, quarterTurns, reflected := tt.ParseOrientedName(tileName)
+	return quarterTurns, quarterTurns != 0 || reflected
+}
+
+// Rendering a small square next to the side where the north side of the tile type is
+func renderOrientationMarker(img *image.RGBA, tileName string, rect image.Rectangle) {
+	side, ok := orientationMarkerSide(tileName)
+
+	if !ok {
+		return
+	}
+
+	size := rect.Dx()
+	marker := size / 8
+	if marker < 1 {
+		marker = 1
+	}
+
+	centers := [4]image.Point{
+		{rect.Min.X + size/2, rect.Min.Y + marker},
+		{rect.Max.X - marker, rect.Min.Y + size/2},
+		{rect.Min.X + size/2, rect.Max.Y - marker},
+		{rect.Min.X + marker, rect.Min.Y + size/2},
+	}
+	center := centers[side]
+
+	fillRect(img, image.Rect(center.X-marker/2, center.Y-marker/2, center.X-marker/2+marker, center.Y-marker/2+marker).Intersect(img.Rect), ORIENTATION_COLOR)
+}
+
+// Rendering the outline and diagonals of the tile
+func renderLocalGrid(img *image.RGBA, rect image.Rectangle) {
+	upperLeft := image.Point{rect.Min.X, rect.Min.Y}
+	upperRight := image.Point{rect.Max.X - 1, rect.Min.Y}
+	lowerRight := image.Point{rect.Max.X - 1, rect.Max.Y - 1}
+	lowerLeft := image.Point{rect.Min.X, rect.Max.Y - 1}
+
+	drawLine(img, upperLeft, upperRight, GRID_COLOR)
+	drawLine(img, upperRight, lowerRight, GRID_COLOR)
+	drawLine(img, lowerRight, lowerLeft, GRID_COLOR)
+	drawLine(img, lowerLeft, upperLeft, GRID_COLOR)
+	drawLine(img, upperLeft, lowerRight, GRID_COLOR)
+	drawLine(img, upperRight, lowerLeft, GRID_COLOR)
+}
+
+// Rendering the name of the tile in its center and the name of its glues next to each side
+func renderTileText(img *image.RGBA, tile tt.SquareGlues, tileName string, rect image.Rectangle) {
+	size := rect.Dx()
+	center := image.Point{rect.Min.X + size/2, rect.Min.Y + size/2}
+
+	if tileName != "" {
+		if scale := fittingScale(tileName, size/2, size/4); scale > 0 {
+			drawText(img, tileName, center, scale, TILE_NAME_COLOR)
+		}
+	}
+
+	// Glue names are drawn slightly inside the tile, in the triangle of their side
+	inside := size / 6
+	gluesCenter := [4]image.Point{
+		{center.X, rect.Min.Y + inside},
+		{rect.Max.X - inside, center.Y},
+		{center.X, rect.Max.Y - inside},
+		{rect.Min.X + inside, center.Y},
+	}
+
+	for i, glueName := range tile[:4] {
+		if glueName == tt.NULL_GLUE {
+			continue
+		}
+		if scale := fittingScale(glueName, size/4, size/6); scale > 0 {
+			drawText(img, glueName, gluesCenter[i], scale, GLUE_NAME_COLOR)
+		}
+	}
+}
+
+// Draws the tiles on the image which covers the region of the parameters
+func drawTileMap(img *image.RGBA, tileSet tt.TileSet, tileMap tt.TileMap, params RenderParameters) {
+	region := params.regionOf(tileMap)
+	tileNames := tileSet.TileNames()
+
+	for tilePos, tile := range tileMap {
+		rect := tileRect(tilePos, region, params.TileSize)
+
+		if !rect.Overlaps(img.Rect) {
+			continue
+		}
+
+		if params.ShowTiles {
+			renderTile(img, tile, rect)
+			renderOrientationMarker(img, tileNames[tile], rect)
+		}
+
+		if params.ShowGrid {
+			renderLocalGrid(img, rect)
+		}
+
+		if params.ShowTilesText {
+			renderTileText(img, tile, tileNames[tile], rect)
+		}
+	}
+}
+
+// Renders the tiles to a new image, tile names are looked up in the tile set.
+// Returns an error if the region of the parameters is empty.
+func RenderTileMap(tileSet tt.TileSet, tileMap tt.TileMap, params RenderParameters) (*image.RGBA, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	region := params.regionOf(tileMap)
+	params.Region = &region
+
+	width := (region.UpperRight[0] - region.LowerLeft[0] + 1) * params.TileSize
+	height := (region.UpperRight[1] - region.LowerLeft[1] + 1) * params.TileSize
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, img.Rect, BACKGROUND_COLOR)
+
+	drawTileMap(img, tileSet, tileMap, params)
+
+	return img, nil
+}
+
+// Returns an error if the tiles of the assembly are not square
+func RenderAssembly(assembly tt.TileAssembly, params RenderParameters) (*image.RGBA, error) {
+	if err := CheckLattice(assembly.GetLattice()); err != nil {
+		return nil, err
+	}
+	return RenderTileMap(assembly.GetOrientedTileSet(), assembly.GetTileMap(), params)
+}
+
+func WritePNG(w io.Writer, assembly tt.TileAssembly, params RenderParameters) error {
+	img, err := RenderAssembly(assembly, params)
+
+	if err != nil {
+		return err
+	}
+
+	return png.Encode(w, img)
+}
+
+func SavePNG(path string, assembly tt.TileAssembly, params RenderParameters) error {
+	file, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	err = WritePNG(file, assembly, params)
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
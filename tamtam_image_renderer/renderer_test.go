@@ -0,0 +1,163 @@
+package tamtam_image_renderer
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"reflect"
+	tt "tamtam/tamtam"
+	"testing"
+)
+
+// Probing the pixels of a single tile: glue triangles, grid, tile name and glue names
+func TestRenderTileMap(t *testing.T) {
+	tile := tt.SquareGlues{"0", "1", "x", tt.NULL_GLUE}
+	tileSet := tt.TileSet{"1": tile}
+	tileMap := tt.TileMap{tt.Vec2Di{0, 0}: tile}
+
+	params := NewRenderParameters()
+	params.ShowTilesText = true
+	img, err := RenderTileMap(tileSet, tileMap, params)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if img.Rect != image.Rect(0, 0, TILE_SIZE, TILE_SIZE) {
+		t.Fatalf(`Unexpected image bounds %v`, img.Rect)
+	}
+
+	for _, probe := range []struct {
+		name  string
+		point image.Point
+		color color.RGBA
+	}{
+		{"north glue", image.Point{10, 3}, COLOR_WHEEL[0]},
+		{"east glue", image.Point{28, 10}, COLOR_WHEEL[1]},
+		{"south glue", image.Point{10, 28}, GlueColor("x")},
+		{"null west glue", image.Point{3, 10}, BACKGROUND_COLOR},
+		{"outline", image.Point{0, 0}, GRID_COLOR},
+		{"diagonal", image.Point{5, 5}, GRID_COLOR},
+		{"anti-diagonal", image.Point{26, 5}, GRID_COLOR},
+		// Upper pixel of the 1 glyph at scale 1, centered on the tile
+		{"tile name", image.Point{16, 14}, TILE_NAME_COLOR},
+		// Upper left pixel of the 0 glyph, centered a sixth of the tile below the north side
+		{"north glue name", image.Point{15, 3}, GLUE_NAME_COLOR},
+	} {
+		if c := img.RGBAAt(probe.point.X, probe.point.Y); c != probe.color {
+			t.Fatalf(`%s at %v: %v instead of %v`, probe.name, probe.point, c, probe.color)
+		}
+	}
+
+	params.ShowTiles = false
+	params.ShowGrid = false
+	params.ShowTilesText = false
+
+	if img, _ := RenderTileMap(tileSet, tileMap, params); img.RGBAAt(10, 3) != BACKGROUND_COLOR {
+		t.Fatalf(`Hidden tile drawn with %v`, img.RGBAAt(10, 3))
+	}
+}
+
+func TestRenderEmptyRegion(t *testing.T) {
+	tile := tt.SquareGlues{"0", "1", "x", tt.NULL_GLUE}
+	tileMap := tt.TileMap{tt.Vec2Di{0, 0}: tile}
+
+	for _, region := range []Region{
+		{LowerLeft: tt.Vec2Di{5, 5}, UpperRight: tt.Vec2Di{0, 0}},
+		{LowerLeft: tt.Vec2Di{0, 5}, UpperRight: tt.Vec2Di{5, 0}},
+		{LowerLeft: tt.Vec2Di{5, 0}, UpperRight: tt.Vec2Di{0, 5}},
+	} {
+		params := NewRenderParameters()
+		params.Region = &region
+
+		if _, err := RenderTileMap(tt.TileSet{"1": tile}, tileMap, params); err == nil {
+			t.Fatalf(`Empty region %v rendered`, region)
+		}
+
+		if err := WriteTileMapSVG(ioutil.Discard, tt.TileSet{"1": tile}, tileMap, params); err == nil {
+			t.Fatalf(`Empty region %v written as SVG`, region)
+		}
+	}
+
+	// A single position is a region
+	params := NewRenderParameters()
+	params.Region = &Region{LowerLeft: tt.Vec2Di{1, 1}, UpperRight: tt.Vec2Di{1, 1}}
+
+	if img, err := RenderTileMap(tt.TileSet{"1": tile}, tileMap, params); err != nil || img.Rect != image.Rect(0, 0, TILE_SIZE, TILE_SIZE) {
+		t.Fatalf(`Single position region not rendered: %v`, err)
+	}
+}
+
+// Rotated tiles get a marker next to the side where the north side of their tile type went
+func TestRenderOrientationMarker(t *testing.T) {
+	tileSet := tt.TileSet{"a": tt.SquareGlues{"0", tt.NULL_GLUE, tt.NULL_GLUE, tt.NULL_GLUE}}
+	rotated := tileSet.WithOrientations(tt.ORIENTATIONS_ROTATIONS)[tt.OrientedName("a", 1, false)]
+
+	params := NewRenderParameters()
+	params.ShowGrid = false
+	img, err := RenderTileMap(tileSet.WithOrientations(tt.ORIENTATIONS_ROTATIONS), tt.TileMap{tt.Vec2Di{0, 0}: rotated}, params)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	// The marker is 4 pixels wide, 4 pixels away from the east side
+	if c := img.RGBAAt(TILE_SIZE-4, TILE_SIZE/2); c != ORIENTATION_COLOR {
+		t.Fatalf(`No orientation marker on the east side, %v`, c)
+	}
+
+	if c := img.RGBAAt(TILE_SIZE/2, 4); c != BACKGROUND_COLOR {
+		t.Fatalf(`Orientation marker on the north side, %v`, c)
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	assembly := newCrtAssembly(t, 3)
+	params := NewRenderParameters()
+
+	var b bytes.Buffer
+
+	if err := WritePNG(&b, assembly, params); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	decoded, err := png.Decode(&b)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if rendered, err := RenderAssembly(assembly, params); err != nil || !reflect.DeepEqual(decoded.(*image.RGBA).Pix, rendered.Pix) {
+		t.Fatalf(`The PNG differs from the rendered image`)
+	}
+}
+
+func TestRenderOtherLattices(t *testing.T) {
+	system := tt.TileSystem{
+		TileSet:      tt.TileSet{"a": tt.Glues{"0", "0", "0", "0", "0", "0"}},
+		InitialTiles: tt.TileMap{tt.Vec2Di{0, 0}: tt.Glues{"0", "0", "0", "0", "0", "0"}},
+		Threshold:    2,
+		GrowthModel:  tt.GrowthModel{Lattice: tt.LATTICE_HEXAGONAL},
+	}
+	assembly, err := system.NewAssembly()
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	var b bytes.Buffer
+
+	if err := WritePNG(&b, assembly, NewRenderParameters()); err == nil {
+		t.Fatalf(`Hexagonal tiles rendered as PNG`)
+	}
+
+	if err := WriteAssemblySVG(&b, assembly, NewRenderParameters()); err == nil {
+		t.Fatalf(`Hexagonal tiles rendered as SVG`)
+	}
+
+	if _, err := RecordGrowth(&assembly, NewAnimationParameters()); err == nil || assembly.GetSteps() != 0 {
+		t.Fatalf(`Growth of hexagonal tiles recorded`)
+	}
+}
@@ -0,0 +1,72 @@
+package tamtam_image_renderer
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	tt "tamtam/tamtam"
+)
+
+// Renders each slice of the 3D assembly holding tiles, from bottom to top, seen from above.
+// All the slices cover the same region, the one of the whole assembly if the parameters have none.
+func RenderSlices(assembly tt.TileAssembly3D, params RenderParameters) (heights []int, images []*image.RGBA, err error) {
+	if err := params.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	tileMap := assembly.GetTileMap()
+
+	if params.Region == nil {
+		lowerCorner, upperCorner := tileMap.BoundingBox()
+		params.Region = &Region{LowerLeft: tt.Vec2Di{lowerCorner[0], lowerCorner[1]}, UpperRight: tt.Vec2Di{upperCorner[0], upperCorner[1]}}
+	}
+
+	heights = tileMap.Heights()
+	for _, z := range heights {
+		img, err := RenderTileMap(assembly.TileSet, tileMap.Slice(z), params)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		images = append(images, img)
+	}
+
+	return heights, images, nil
+}
+
+// Writes the slices of the 3D assembly as PNG files slice_<z>.png in the directory
+func SaveSlices(directory string, assembly tt.TileAssembly3D, params RenderParameters) error {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return err
+	}
+
+	heights, images, err := RenderSlices(assembly, params)
+
+	if err != nil {
+		return err
+	}
+
+	for i, img := range images {
+		file, err := os.Create(filepath.Join(directory, fmt.Sprintf("slice_%d.png", heights[i])))
+
+		if err != nil {
+			return err
+		}
+
+		err = png.Encode(file, img)
+
+		if err != nil {
+			file.Close()
+			return err
+		}
+
+		if err = file.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
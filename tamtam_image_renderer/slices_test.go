@@ -0,0 +1,85 @@
+package tamtam_image_renderer
+
+import (
+	"image"
+	"io/ioutil"
+	"reflect"
+	tt "tamtam/tamtam"
+	"testing"
+)
+
+// Two tiles in the bottom slice and one two slices above: the empty slice is skipped and both
+// pictures cover the whole assembly seen from above
+func TestRenderSlices(t *testing.T) {
+	bottom := tt.CubeGlues{"0", "1", tt.NULL_GLUE, tt.NULL_GLUE, "u", tt.NULL_GLUE}
+	corner := tt.CubeGlues{tt.NULL_GLUE, tt.NULL_GLUE, "2", "1", tt.NULL_GLUE, tt.NULL_GLUE}
+	top := tt.CubeGlues{tt.NULL_GLUE, "x", tt.NULL_GLUE, tt.NULL_GLUE, tt.NULL_GLUE, "d"}
+	tileSet := tt.TileSet{"corner": corner, "top": top}
+
+	assembly := tt.NewAssembly3D(tileSet, nil, tt.TileMap3D{
+		tt.Vec3Di{0, 0, 0}: bottom,
+		tt.Vec3Di{1, 1, 0}: corner,
+		tt.Vec3Di{0, 0, 2}: top,
+	}, 2)
+
+	params := NewRenderParameters()
+	params.ShowTilesText = true
+	heights, images, err := RenderSlices(assembly, params)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !reflect.DeepEqual(heights, []int{0, 2}) || len(images) != 2 {
+		t.Fatalf(`Unexpected slices %v`, heights)
+	}
+
+	region := Region{LowerLeft: tt.Vec2Di{0, 0}, UpperRight: tt.Vec2Di{1, 1}}
+	params.Region = &region
+
+	for i, slice := range []tt.TileMap{
+		{tt.Vec2Di{0, 0}: bottom, tt.Vec2Di{1, 1}: corner},
+		{tt.Vec2Di{0, 0}: top},
+	} {
+		if images[i].Rect != image.Rect(0, 0, 2*TILE_SIZE, 2*TILE_SIZE) {
+			t.Fatalf(`Slice %d has bounds %v`, heights[i], images[i].Rect)
+		}
+
+		if want, _ := RenderTileMap(tileSet, slice, params); !reflect.DeepEqual(images[i].Pix, want.Pix) {
+			t.Fatalf(`Slice %d differs from the picture of its tiles`, heights[i])
+		}
+	}
+
+	// The corner of the bottom slice is not drawn in the top one
+	rect := tileRect(tt.Vec2Di{1, 1}, region, TILE_SIZE)
+
+	if c := images[0].RGBAAt(rect.Min.X+10, rect.Max.Y-3); c != GlueColor("2") {
+		t.Fatalf(`Missing corner tile in the bottom slice, %v`, c)
+	}
+
+	if c := images[1].RGBAAt(rect.Min.X+10, rect.Max.Y-3); c != BACKGROUND_COLOR {
+		t.Fatalf(`Corner tile drawn in the top slice, %v`, c)
+	}
+}
+
+func TestSaveSlices(t *testing.T) {
+	assembly := tt.NewAssembly3D(tt.TileSet{}, nil, tt.TileMap3D{
+		tt.Vec3Di{0, 0, -1}: tt.CubeGlues{"0"},
+		tt.Vec3Di{0, 0, 1}:  tt.CubeGlues{"1"},
+	}, 1)
+	directory := t.TempDir()
+
+	if err := SaveSlices(directory, assembly, NewRenderParameters()); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	files, err := ioutil.ReadDir(directory)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if len(files) != 2 || files[0].Name() != "slice_-1.png" || files[1].Name() != "slice_1.png" {
+		t.Fatalf(`Unexpected files %v`, files)
+	}
+}
@@ -0,0 +1,260 @@
+package tamtam_image_renderer
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	tt "tamtam/tamtam"
+)
+
+// Space between tiles and below each tile for its name in tile set sheets, in units of tile size
+const SHEET_MARGIN = 0.25
+const SHEET_NAME_HEIGHT = 0.4
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Formats coordinates with at most two decimals and without trailing zeros
+func svgNumber(x float64) string {
+	return strconv.FormatFloat(math.Round(x*100)/100, 'f', -1, 64)
+}
+
+func svgText(w *bufio.Writer, text string, x float64, y float64, fontSize float64, textColor color.RGBA) {
+	fmt.Fprintf(w, `<text x="%s" y="%s" font-size="%s" fill="%s">`, svgNumber(x), svgNumber(y), svgNumber(fontSize), svgColor(textColor))
+	xml.EscapeText(w, []byte(text))
+	fmt.Fprintln(w, `</text>`)
+}
+
+// Positions sorted from north to south then west to east, the order in which they appear in the picture
+func sortedPositions(tileMap tt.TileMap) []tt.Vec2Di {
+	positions := make([]tt.Vec2Di, 0, len(tileMap))
+	for pos := range tileMap {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i][1] != positions[j][1] {
+			return positions[i][1] > positions[j][1]
+		}
+		return positions[i][0] < positions[j][0]
+	})
+	return positions
+}
+
+// Corners of the square of the tile, clockwise from upper left, and its center
+func svgTileVertices(x float64, y float64, size float64) (vertices [4][2]float64, center [2]float64) {
+	vertices = [4][2]float64{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}}
+	center = [2]float64{x + size/2, y + size/2}
+	return vertices, center
+}
+
+func svgTile(w *bufio.Writer, tile tt.SquareGlues, x float64, y float64, size float64) {
+	vertices, center := svgTileVertices(x, y, size)
+
+	// Side i is the triangle between vertex i, vertex i+1 and the center
+	for i, glue := range tile[:4] {
+		if glue == tt.NULL_GLUE {
+			continue
+		}
+		fmt.Fprintf(w, `<polygon points="%s,%s %s,%s %s,%s" fill="%s"/>`+"\n",
+			svgNumber(vertices[i][0]), svgNumber(vertices[i][1]),
+			svgNumber(vertices[(i+1)%4][0]), svgNumber(vertices[(i+1)%4][1]),
+			svgNumber(center[0]), svgNumber(center[1]), svgColor(GlueColor(glue)))
+	}
+}
+
+// Small circle next to the side where the north side of the tile type is, for oriented tiles
+func svgOrientationMarker(w *bufio.Writer, tileName string, x float64, y float64, size float64) {
+	side, ok := orientationMarkerSide(tileName)
+
+	if !ok {
+		return
+	}
+
+	_, center := svgTileVertices(x, y, size)
+	inside := size / 8
+	centers := [4][2]float64{
+		{center[0], y + inside},
+		{x + size - inside, center[1]},
+		{center[0], y + size - inside},
+		{x + inside, center[1]},
+	}
+
+	fmt.Fprintf(w, `<circle cx="%s" cy="%s" r="%s" fill="%s"/>`+"\n", svgNumber(centers[side][0]), svgNumber(centers[side][1]), svgNumber(size/16), svgColor(ORIENTATION_COLOR))
+}
+
+func svgLocalGrid(w *bufio.Writer, x float64, y float64, size float64) {
+	vertices, _ := svgTileVertices(x, y, size)
+	fmt.Fprintf(w, `<rect x="%s" y="%s" width="%s" height="%s" fill="none"/>`+"\n", svgNumber(x), svgNumber(y), svgNumber(size), svgNumber(size))
+	fmt.Fprintf(w, `<line x1="%s" y1="%s" x2="%s" y2="%s"/>`+"\n", svgNumber(vertices[0][0]), svgNumber(vertices[0][1]), svgNumber(vertices[2][0]), svgNumber(vertices[2][1]))
+	fmt.Fprintf(w, `<line x1="%s" y1="%s" x2="%s" y2="%s"/>`+"\n", svgNumber(vertices[1][0]), svgNumber(vertices[1][1]), svgNumber(vertices[3][0]), svgNumber(vertices[3][1]))
+}
+
+// Same layout as renderTileText: tile name in the center, glue names next to their side
+func svgTileText(w *bufio.Writer, tile tt.SquareGlues, tileName string, x float64, y float64, size float64) {
+	_, center := svgTileVertices(x, y, size)
+
+	if tileName != "" {
+		svgText(w, tileName, center[0], center[1], size/4, TILE_NAME_COLOR)
+	}
+
+	inside := size / 6
+	gluesCenter := [4][2]float64{
+		{center[0], y + inside},
+		{x + size - inside, center[1]},
+		{center[0], y + size - inside},
+		{x + inside, center[1]},
+	}
+
+	for i, glueName := range tile[:4] {
+		if glueName == tt.NULL_GLUE {
+			continue
+		}
+		svgText(w, glueName, gluesCenter[i][0], gluesCenter[i][1], size/6, GLUE_NAME_COLOR)
+	}
+}
+
+func svgHeader(w *bufio.Writer, width float64, height float64) {
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s">`+"\n", svgNumber(width), svgNumber(height), svgNumber(width), svgNumber(height))
+	fmt.Fprintf(w, `<rect x="0" y="0" width="%s" height="%s" fill="%s"/>`+"\n", svgNumber(width), svgNumber(height), svgColor(BACKGROUND_COLOR))
+}
+
+// Writes the tiles as an SVG picture, the output only depends on the tiles and parameters.
+// Returns an error if the region of the parameters is empty.
+func WriteTileMapSVG(w io.Writer, tileSet tt.TileSet, tileMap tt.TileMap, params RenderParameters) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	region := params.regionOf(tileMap)
+	size := float64(params.TileSize)
+
+	width := float64(region.UpperRight[0]-region.LowerLeft[0]+1) * size
+	height := float64(region.UpperRight[1]-region.LowerLeft[1]+1) * size
+
+	var positions []tt.Vec2Di
+	for _, pos := range sortedPositions(tileMap) {
+		if pos[0] >= region.LowerLeft[0] && pos[0] <= region.UpperRight[0] && pos[1] >= region.LowerLeft[1] && pos[1] <= region.UpperRight[1] {
+			positions = append(positions, pos)
+		}
+	}
+
+	tileCorner := func(pos tt.Vec2Di) (float64, float64) {
+		return float64(pos[0]-region.LowerLeft[0]) * size, float64(region.UpperRight[1]-pos[1]) * size
+	}
+
+	bw := bufio.NewWriter(w)
+	svgHeader(bw, width, height)
+
+	if params.ShowTiles {
+		tileNames := tileSet.TileNames()
+		fmt.Fprintln(bw, `<g id="tiles">`)
+		for _, pos := range positions {
+			x, y := tileCorner(pos)
+			svgTile(bw, tileMap[pos], x, y, size)
+			svgOrientationMarker(bw, tileNames[tileMap[pos]], x, y, size)
+		}
+		fmt.Fprintln(bw, `</g>`)
+	}
+
+	if params.ShowGrid {
+		fmt.Fprintf(bw, `<g id="grid" stroke="%s" stroke-width="1">`+"\n", svgColor(GRID_COLOR))
+		for _, pos := range positions {
+			x, y := tileCorner(pos)
+			svgLocalGrid(bw, x, y, size)
+		}
+		fmt.Fprintln(bw, `</g>`)
+	}
+
+	if params.ShowTilesText {
+		tileNames := tileSet.TileNames()
+		fmt.Fprintln(bw, `<g id="text" font-family="sans-serif" font-weight="bold" text-anchor="middle" dominant-baseline="central">`)
+		for _, pos := range positions {
+			x, y := tileCorner(pos)
+			svgTileText(bw, tileMap[pos], tileNames[tileMap[pos]], x, y, size)
+		}
+		fmt.Fprintln(bw, `</g>`)
+	}
+
+	fmt.Fprintln(bw, `</svg>`)
+	return bw.Flush()
+}
+
+// Returns an error if the tiles of the assembly are not square
+func WriteAssemblySVG(w io.Writer, assembly tt.TileAssembly, params RenderParameters) error {
+	if err := CheckLattice(assembly.GetLattice()); err != nil {
+		return err
+	}
+	return WriteTileMapSVG(w, assembly.GetOrientedTileSet(), assembly.GetTileMap(), params)
+}
+
+// Writes every tile type of the tile set, sorted by name, with its name written below it.
+// Tiles are laid out in rows of the given number of columns, a square layout is used if columns <= 0.
+func WriteTileSetSheetSVG(w io.Writer, tileSet tt.TileSet, columns int, params RenderParameters) error {
+	names := tileSet.SortedNames()
+
+	if columns <= 0 {
+		columns = int(math.Ceil(math.Sqrt(float64(len(names)))))
+		if columns == 0 {
+			columns = 1
+		}
+	}
+	rows := (len(names) + columns - 1) / columns
+
+	size := float64(params.TileSize)
+	cellWidth := size * (1 + SHEET_MARGIN)
+	cellHeight := size * (1 + SHEET_MARGIN + SHEET_NAME_HEIGHT)
+
+	bw := bufio.NewWriter(w)
+	svgHeader(bw, float64(columns)*cellWidth+SHEET_MARGIN*size, float64(rows)*cellHeight+SHEET_MARGIN*size)
+
+	fmt.Fprintf(bw, `<g font-family="sans-serif" font-weight="bold" text-anchor="middle" dominant-baseline="central" stroke-width="1">`+"\n")
+	for i, name := range names {
+		x := SHEET_MARGIN*size + float64(i%columns)*cellWidth
+		y := SHEET_MARGIN*size + float64(i/columns)*cellHeight
+		tile := tileSet[name]
+
+		if params.ShowTiles {
+			svgTile(bw, tile, x, y, size)
+		}
+
+		if params.ShowGrid {
+			fmt.Fprintf(bw, `<g stroke="%s">`+"\n", svgColor(GRID_COLOR))
+			svgLocalGrid(bw, x, y, size)
+			fmt.Fprintln(bw, `</g>`)
+		}
+
+		if params.ShowTilesText {
+			svgTileText(bw, tile, "", x, y, size)
+		}
+
+		svgText(bw, name, x+size/2, y+size+SHEET_NAME_HEIGHT*size/2, SHEET_NAME_HEIGHT*size*0.75, TILE_NAME_COLOR)
+	}
+	fmt.Fprintln(bw, `</g>`)
+
+	fmt.Fprintln(bw, `</svg>`)
+	return bw.Flush()
+}
+
+func SaveAssemblySVG(path string, assembly tt.TileAssembly, params RenderParameters) error {
+	file, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	err = WriteAssemblySVG(file, assembly, params)
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
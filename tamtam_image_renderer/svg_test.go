@@ -0,0 +1,89 @@
+package tamtam_image_renderer
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	tt "tamtam/tamtam"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func compareToGolden(t *testing.T, goldenFile string, got []byte) {
+	goldenPath := filepath.Join("testdata", goldenFile)
+
+	if *updateGolden {
+		if err := ioutil.WriteFile(goldenPath, got, 0644); err != nil {
+			t.Fatalf(`%v`, err)
+		}
+	}
+
+	want, err := ioutil.ReadFile(goldenPath)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf(`Output differs from %s, run the tests with -update if the change is intended`, goldenPath)
+	}
+}
+
+// Seed of a small CRT assembly: a column of 0 glues and a row counting from 1
+func newCrtAssembly(t *testing.T, size int) tt.TileAssembly {
+	tileSet, err := tt.NewCrtTileSet(2, 3)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	initialTiles := make(tt.TileMap)
+
+	for i := 0; i < size; i += 1 {
+		initialTiles[tt.Vec2Di{-1, i}] = tt.SquareGlues{tt.NULL_GLUE, "0", tt.NULL_GLUE, tt.NULL_GLUE}
+	}
+
+	initialTiles[tt.Vec2Di{0, -1}] = tt.SquareGlues{"1", tt.NULL_GLUE, tt.NULL_GLUE, tt.NULL_GLUE}
+
+	for i := 0; i < size-1; i += 1 {
+		initialTiles[tt.Vec2Di{1 + i, -1}] = tt.SquareGlues{"0", tt.NULL_GLUE, tt.NULL_GLUE, tt.NULL_GLUE}
+	}
+
+	return tt.NewAssembly(tileSet, initialTiles, 2)
+}
+
+// Testing that SVG outputs of a small CRT assembly and its tile set match the golden files
+func TestSVGGolden(t *testing.T) {
+	assembly := newCrtAssembly(t, 3)
+
+	didGrow, err := assembly.GrowSync(true)
+
+	for didGrow && err == nil {
+		didGrow, err = assembly.GrowSync(true)
+	}
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	params := NewRenderParameters()
+	params.ShowTilesText = true
+
+	var b bytes.Buffer
+
+	if err := WriteAssemblySVG(&b, assembly, params); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	compareToGolden(t, "crt_assembly.svg", b.Bytes())
+
+	b.Reset()
+
+	if err := WriteTileSetSheetSVG(&b, assembly.TileSet, 0, params); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	compareToGolden(t, "crt_tile_set.svg", b.Bytes())
+}
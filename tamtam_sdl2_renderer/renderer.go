@@ -16,6 +16,11 @@ const TILE_SIZE = 64
 var BACKGROUND_COLOR = [4]uint8{0.4 * 255, 0.4 * 255, 0.4 * 255}
 var COLOR_WHEEL = [][4]uint8{{229, 198, 146, 255}, {20, 196, 52, 255}, {227, 121, 151, 255}}
 
+// Colors of the diff overlay
+var ONLY_IN_FIRST_COLOR = [4]uint8{0, 0, 255, 128}
+var ONLY_IN_SECOND_COLOR = [4]uint8{0, 255, 0, 128}
+var DIFFERENT_TILE_COLOR = [4]uint8{255, 0, 0, 128}
+
 // Absolute screen coordinates (as well as textureCoordinates) take the assumption that going NORTH is y + 1
 // going EAST is x + 1. That does not match SDL internal convention. This gets corrected at render time.
 type screenCoordinates tt.Vec2Di
@@ -40,13 +45,19 @@ type textureCoordinates tt.Vec2Di
 type SDL2AssemblyRenderer struct {
 	sdlRenderer           *sdl.Renderer
 	assembly              *tt.TileAssembly
+	changes               *tt.ChangeLog
 	font                  *ttf.Font
 	tilesTextureCache     map[screenCoordinates]*sdl.Texture
 	gridTextureCache      map[screenCoordinates]*sdl.Texture
 	tilesTextTextureCache map[screenCoordinates]*sdl.Texture
 }
 
-func NewSDL2AssemblyRenderer(assembly *tt.TileAssembly, sdlRenderer *sdl.Renderer) (assemblyRenderer SDL2AssemblyRenderer) {
+// Returns an error if the tiles of the assembly are not square, the only ones that are drawn
+func NewSDL2AssemblyRenderer(assembly *tt.TileAssembly, sdlRenderer *sdl.Renderer) (assemblyRenderer SDL2AssemblyRenderer, err error) {
+
+	if lattice := assembly.GetLattice(); lattice != tt.LATTICE_SQUARE {
+		return assemblyRenderer, fmt.Errorf("only square tiles can be rendered, not %s ones", lattice)
+	}
 
 	assemblyRenderer.assembly = assembly
 	assemblyRenderer.sdlRenderer = sdlRenderer
@@ -56,17 +67,23 @@ func NewSDL2AssemblyRenderer(assembly *tt.TileAssembly, sdlRenderer *sdl.Rendere
 	// Text textures
 	assemblyRenderer.tilesTextTextureCache = make(map[screenCoordinates]*sdl.Texture)
 
-	var err error
 	assemblyRenderer.font, err = ttf.OpenFont("assets/calibri-bold.ttf", 32)
 
 	if err != nil {
-		panic(err)
+		return assemblyRenderer, err
+	}
+
+	// The tiles already there are rendered as if they had just been added
+	assemblyRenderer.changes = tt.NewChangeLog()
+	for pos, tile := range assembly.GetTileMap() {
+		assemblyRenderer.changes.OnTileAdded(pos, tile)
 	}
+	assembly.AddObserver(assemblyRenderer.changes)
 
 	fmt.Println("Creating assembly renderer")
 	assemblyRenderer.UpdateTextures()
 
-	return assemblyRenderer
+	return assemblyRenderer, nil
 }
 
 func assemblyPosToScreenCoordinates(tilePos tt.Vec2Di) screenCoordinates {
@@ -131,7 +148,7 @@ func (assemblyRenderer *SDL2AssemblyRenderer) renderTileText(texture *sdl.Textur
 	assemblyRenderer.font.SetStyle(ttf.STYLE_BOLD)
 
 	// Render tile name
-	tileName, err := assemblyRenderer.assembly.TileSet.GetTileName(tile)
+	tileName, err := assemblyRenderer.assembly.GetOrientedTileSet().GetTileName(tile)
 
 	if err == nil {
 		assemblyRenderer.sdlRenderer.SetRenderTarget(texture)
@@ -153,7 +170,7 @@ func (assemblyRenderer *SDL2AssemblyRenderer) renderTileText(texture *sdl.Textur
 
 	// Render tile glues
 	assemblyRenderer.sdlRenderer.SetRenderTarget(texture)
-	for i, glueName := range tile {
+	for i, glueName := range tile[:4] {
 
 		if glueName == tt.NULL_GLUE {
 			continue
@@ -217,8 +234,50 @@ func (assemblyRenderer *SDL2AssemblyRenderer) renderTile(texture *sdl.Texture, t
 	assemblyRenderer.sdlRenderer.SetRenderTarget(nil)
 }
 
+// Erasing a removed tile from the tile, grid and tile text textures
+func (assemblyRenderer *SDL2AssemblyRenderer) clearTile(tilePos tt.Vec2Di) {
+	textureLeftCornerCoord := getTileTextureLeftCornerCoord(tilePos)
+
+	if _, ok := assemblyRenderer.tilesTextureCache[textureLeftCornerCoord]; !ok {
+		return
+	}
+
+	screenCoord := assemblyPosToScreenCoordinates(tilePos)
+
+	coordInTexture := textureCoordinates{screenCoord[0] - textureLeftCornerCoord[0], screenCoord[1] - textureLeftCornerCoord[1]}
+
+	tileRect := sdl.Rect{int32(coordInTexture[0]), int32(coordInTexture[1]), TILE_SIZE, TILE_SIZE}
+
+	// Overwriting the pixels of the tile instead of blending with them, the previous mode is restored below
+	var blendMode sdl.BlendMode
+	assemblyRenderer.sdlRenderer.GetDrawBlendMode(&blendMode)
+	assemblyRenderer.sdlRenderer.SetDrawBlendMode(sdl.BLENDMODE_NONE)
+
+	assemblyRenderer.sdlRenderer.SetRenderTarget(assemblyRenderer.tilesTextureCache[textureLeftCornerCoord])
+	assemblyRenderer.sdlRenderer.SetDrawColor(100, 0, 0, BACKGROUND_COLOR[3])
+	assemblyRenderer.sdlRenderer.FillRect(&tileRect)
+
+	assemblyRenderer.sdlRenderer.SetRenderTarget(assemblyRenderer.gridTextureCache[textureLeftCornerCoord])
+	assemblyRenderer.sdlRenderer.SetDrawColor(0, 0, 0, 0)
+	assemblyRenderer.sdlRenderer.FillRect(&tileRect)
+
+	assemblyRenderer.sdlRenderer.SetRenderTarget(assemblyRenderer.tilesTextTextureCache[textureLeftCornerCoord])
+	assemblyRenderer.sdlRenderer.SetDrawColor(0, 0, 0, 0)
+	assemblyRenderer.sdlRenderer.FillRect(&tileRect)
+
+	assemblyRenderer.sdlRenderer.SetRenderTarget(nil)
+	assemblyRenderer.sdlRenderer.SetDrawBlendMode(blendMode)
+}
+
 func (assemblyRenderer *SDL2AssemblyRenderer) UpdateTextures() {
-	for _, tileAndPos := range assemblyRenderer.assembly.GetNewlyAddedTiles() {
+	// Removed tiles first, their position might have been filled again since
+	for _, tileAndPos := range assemblyRenderer.changes.GetRemovedTiles() {
+		assemblyRenderer.clearTile(tileAndPos.Pos)
+	}
+
+	assemblyRenderer.changes.FlushRemovedTiles()
+
+	for _, tileAndPos := range assemblyRenderer.changes.GetAddedTiles() {
 		textureLeftCornerCoord := getTileTextureLeftCornerCoord(tileAndPos.Pos)
 		// If the texture does not exists we create it
 		if _, ok := assemblyRenderer.tilesTextureCache[textureLeftCornerCoord]; !ok {
@@ -285,7 +344,7 @@ func (assemblyRenderer *SDL2AssemblyRenderer) UpdateTextures() {
 		assemblyRenderer.renderTileText(assemblyRenderer.tilesTextTextureCache[textureLeftCornerCoord], tileAndPos.Tile, tileAndPos.Pos)
 	}
 
-	assemblyRenderer.assembly.FlushNewlyAddedTiles()
+	assemblyRenderer.changes.FlushAddedTiles()
 }
 
 // Rendering the scene and correcting here the difference in convention
@@ -327,11 +386,44 @@ func (assemblyRenderer *SDL2AssemblyRenderer) Render(uiParams UIParameters) {
 	}
 }
 
+// Rectangle covered by the tile on screen, following the conventions of Render
+func tileScreenRect(tilePos tt.Vec2Di, uiParams UIParameters) sdl.FRect {
+	screenCoord := assemblyPosToScreenCoordinates(tilePos)
+	zoom := uiParams.Camera.ZoomFactor
+	return sdl.FRect{float32(screenCoord[0]-uiParams.Camera.Translation[0]) * zoom, float32(uiParams.Camera.Translation[1]+TEXTURE_SIZE-screenCoord[1]-TILE_SIZE) * zoom, TILE_SIZE * zoom, TILE_SIZE * zoom}
+}
+
+// Covering the positions where the assembly differs from another one, to be called after Render.
+// Positions are colored according to whether their tile is only in the rendered assembly,
+// only in the other one or different in both.
+func (assemblyRenderer *SDL2AssemblyRenderer) RenderDiffOverlay(diff tt.TileMapDiff, uiParams UIParameters) {
+	assemblyRenderer.sdlRenderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+
+	fill := func(pos tt.Vec2Di, color [4]uint8) {
+		rect := tileScreenRect(pos, uiParams)
+		assemblyRenderer.sdlRenderer.SetDrawColor(color[0], color[1], color[2], color[3])
+		assemblyRenderer.sdlRenderer.FillRectF(&rect)
+	}
+
+	for _, posAndTile := range diff.OnlyInFirst {
+		fill(posAndTile.Pos, ONLY_IN_FIRST_COLOR)
+	}
+
+	for _, posAndTile := range diff.OnlyInSecond {
+		fill(posAndTile.Pos, ONLY_IN_SECOND_COLOR)
+	}
+
+	for _, difference := range diff.Different {
+		fill(difference.Pos, DIFFERENT_TILE_COLOR)
+	}
+}
+
 func (assemblyRenderer SDL2AssemblyRenderer) CountTextures() int {
 	return len(assemblyRenderer.tilesTextureCache)
 }
 
 func (assemblyRenderer *SDL2AssemblyRenderer) Destroy() {
+	assemblyRenderer.assembly.RemoveObserver(assemblyRenderer.changes)
 	assemblyRenderer.font.Close()
 	for _, texture := range assemblyRenderer.tilesTextureCache {
 		texture.Destroy()
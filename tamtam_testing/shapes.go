@@ -0,0 +1,59 @@
+// Helpers for the tests of tile sets and of the generators that build them
+package tamtam_testing
+
+import (
+	"bytes"
+	"fmt"
+	tt "tamtam/tamtam"
+	"testing"
+)
+
+// Number of synchronous steps after which CheckShape gives up when given no limit
+const DEFAULT_MAX_STEPS = 10000
+
+// Grows the tile system until it is terminal and returns an error describing the differences
+// if its domain is not the target shape up to translation, see tt.VerifyTerminalShape.
+// maxSteps defaults to DEFAULT_MAX_STEPS if 0.
+func CheckShape(system tt.TileSystem, target tt.Shape, maxSteps int) error {
+	if maxSteps == 0 {
+		maxSteps = DEFAULT_MAX_STEPS
+	}
+
+	verification, assembly, err := tt.VerifyTerminalShape(system, target, maxSteps)
+
+	if err != nil {
+		return err
+	}
+
+	if verification.IsExact() {
+		return nil
+	}
+
+	var text bytes.Buffer
+	verification.WriteText(&text)
+
+	return fmt.Errorf("the terminal assembly is not the target shape, %d positions are missing and %d are extra:\n%s\nterminal assembly:\n%s\ntarget shape:\n%s",
+		len(verification.Missing), len(verification.Extra), text.String(), assembly.GetTileMap().Domain().ASCII(), target.ASCII())
+}
+
+// Fails the test if the terminal assembly of the tile system is not the target shape up to translation
+func AssertShape(t testing.TB, system tt.TileSystem, target tt.Shape, maxSteps int) {
+	t.Helper()
+
+	if err := CheckShape(system, target, maxSteps); err != nil {
+		t.Fatalf(`%v`, err)
+	}
+}
+
+// Same as AssertShape, with the target shape drawn as ASCII art (see tt.ParseASCIIShape)
+func AssertASCIIShape(t testing.TB, system tt.TileSystem, target string, maxSteps int) {
+	t.Helper()
+
+	shape, err := tt.ParseASCIIShape(target)
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	AssertShape(t, system, shape, maxSteps)
+}
@@ -0,0 +1,46 @@
+package tamtam_testing
+
+import (
+	"path/filepath"
+	"strings"
+	tt "tamtam/tamtam"
+	"testing"
+)
+
+func loadSierpinskiInBox(t *testing.T, upperRight tt.Vec2Di) tt.TileSystem {
+	system, err := tt.LoadTASSystem(filepath.Join("..", "tamtam", "testdata", "sierpinski.tdp"))
+
+	if err != nil {
+		t.Fatalf(`%v`, err)
+	}
+
+	system.Geometry = &tt.Geometry{Kind: tt.GEOMETRY_BOUNDED, UpperRight: upperRight}
+	return system
+}
+
+// The Sierpinski system fills its box
+func TestAssertShape(t *testing.T) {
+	AssertASCIIShape(t, loadSierpinskiInBox(t, tt.Vec2Di{3, 2}), `
+####
+####
+####
+`, 0)
+}
+
+func TestCheckShape(t *testing.T) {
+	target, _ := tt.ParseASCIIShape("####\n####\n###.")
+
+	err := CheckShape(loadSierpinskiInBox(t, tt.Vec2Di{3, 2}), target, 0)
+
+	if err == nil || !strings.Contains(err.Error(), "0 positions are missing and 1 are extra") || !strings.Contains(err.Error(), "+ [3 0]") {
+		t.Fatalf(`Unexpected error %v`, err)
+	}
+
+	// The Sierpinski system grows forever on the plane
+	system := loadSierpinskiInBox(t, tt.Vec2Di{3, 2})
+	system.Geometry = nil
+
+	if err := CheckShape(system, target, 20); err == nil {
+		t.Fatalf(`Infinite growth not reported`)
+	}
+}